@@ -0,0 +1,77 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImportErrors are the errors of all the imports which failed to load in aggregation mode,
+// errors.Is matches the kinds of the failures like ImportNotFoundErr
+type ImportErrors struct {
+	Errors []error
+	format func([]error) string
+}
+
+// Error implements error, the errors are joined with semicolons unless a formatter is set with WithErrorFormatter
+func (e *ImportErrors) Error() string {
+	if e.format != nil {
+		return e.format(e.Errors)
+	}
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d imports failed: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the imports, so errors.Is and errors.As match any of them
+func (e *ImportErrors) Unwrap() []error {
+	return e.Errors
+}
+
+// skipFailed checks if processing goes on after the import failed with the error
+// The import is marked as corrupted, and the error is kept in aggregation mode unless the import ignores errors
+func (o *options) skipFailed(ci *configImport, err error) bool {
+	switch {
+	case isCancelled(err):
+		return false
+	case ci.IgnoreErrors:
+		ci.corrupted, ci.ignoredErr = true, err
+		if o.onIgnoredError != nil {
+			o.onIgnoredError(ci.Resource, err)
+		}
+	case o.aggregateErrors:
+		ci.corrupted, ci.err = true, err
+	default:
+		return false
+	}
+
+	return true
+}
+
+// skipDiscoveryFailed checks if imports discovery goes on after the import failed with the error
+// With preflight validation the errors of all the imports are collected, and processing fails before loading
+func (o *options) skipDiscoveryFailed(ci *configImport, err error) bool {
+	if o.preflightValidation && !ci.IgnoreErrors && !isCancelled(err) {
+		ci.corrupted, ci.err = true, err
+		return true
+	}
+
+	return o.skipFailed(ci, err)
+}
+
+// importErrors returns ImportErrors with the errors collected in aggregation mode, nil if there are none
+func (o *options) importErrors(importList []configImport) error {
+	var errs []error
+	for _, ci := range importList {
+		if ci.err != nil {
+			errs = append(errs, ci.err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &ImportErrors{Errors: errs, format: o.errorFormatter}
+}
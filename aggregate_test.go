@@ -0,0 +1,103 @@
+package yaml
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithErrorFormatter(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: missing.yml}\n - {resource: broken.yml}\n - {resource: db.yml}\nname: app"),
+		"broken.yml": []byte("db: [unclosed"),
+		"db.yml":     []byte("db: {host: localhost}"),
+	}
+	reader := func(filename string) ([]byte, error) {
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("open " + filename + ": no such file")
+	}
+	type testStruct struct {
+		Name string
+		DB   struct{ Host string }
+	}
+	bulleted := func(errs []error) string {
+		lines := []string{"config errors:"}
+		for _, err := range errs {
+			lines = append(lines, "  - "+err.Error())
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithErrorFormatter(bulleted))
+	assert.EqualError(t, err, "config.yml -> broken.yml: yaml: line 1: did not find expected ',' or ']'", "the formatter isn't used without aggregation")
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithAggregateErrors(), WithErrorFormatter(bulleted))
+	assert.EqualError(t, err, "config errors:\n"+
+		"  - config.yml -> broken.yml: yaml: line 1: did not find expected ',' or ']'\n"+
+		"  - config.yml -> missing.yml: open missing.yml: no such file")
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "localhost", ts.DB.Host)
+
+	var importErrors *ImportErrors
+	assert.True(t, errors.As(err, &importErrors))
+	assert.Len(t, importErrors.Errors, 2)
+	assert.True(t, errors.Is(err, ImportParseErr))
+	assert.True(t, errors.Is(err, ImportReadErr), "the reader doesn't report os.ErrNotExist")
+	assert.False(t, errors.Is(err, ImportNotFoundErr))
+	var importErr *ImportError
+	if assert.True(t, errors.As(err, &importErr)) {
+		assert.Equal(t, "broken.yml", importErr.Resource)
+	}
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithAggregateErrors())
+	assert.EqualError(t, err, "2 imports failed: config.yml -> broken.yml: yaml: line 1: did not find expected ',' or ']'; config.yml -> missing.yml: open missing.yml: no such file")
+}
+
+func TestWithPreflightValidation(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n" +
+			" - {resource: db.yml}\n" +
+			" - {resource: missing.yml}\n" +
+			" - {resource: optional.yml, ignore_errors: true}\n" +
+			" - {resource: prod.yml, when_env: YAML_TEST_PREFLIGHT_ENV}\n" +
+			" - {resource: broken.yml}\n" +
+			"name: app"),
+		"db.yml":     []byte("imports:\n - {resource: db_missing.yml}\ndb: {host: localhost}"),
+		"broken.yml": []byte("db: [unclosed"),
+	}
+	reader := func(filename string) ([]byte, error) {
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("open " + filename + ": no such file")
+	}
+	os.Unsetenv("YAML_TEST_PREFLIGHT_ENV")
+	type testStruct struct {
+		Name string
+		DB   struct{ Host string }
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithPreflightValidation())
+	assert.EqualError(t, err, "3 imports failed: config.yml -> broken.yml: yaml: line 1: did not find expected ',' or ']'; "+
+		"config.yml -> missing.yml: open missing.yml: no such file; "+
+		"config.yml -> db.yml -> db_missing.yml: open db_missing.yml: no such file")
+	assert.Equal(t, testStruct{}, ts, "dst is not changed")
+	assert.True(t, errors.Is(err, ImportReadErr))
+	assert.True(t, errors.Is(err, ImportParseErr))
+
+	files["missing.yml"] = []byte("name: missing")
+	files["db_missing.yml"] = []byte("db: {host: primary}")
+	files["broken.yml"] = []byte("db: {host: replica}")
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithPreflightValidation())
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "replica", ts.DB.Host)
+}
@@ -0,0 +1,38 @@
+package yaml
+
+import (
+	"io/ioutil"
+	"sync"
+)
+
+// CachingReader returns a reader which memoizes the files read with reader by their names, ioutil.ReadFile is used
+// if it's nil. The failed reads are not cached, so missing files are read again. The reader is safe for concurrent use,
+// the returned data is shared by the callers and must not be modified
+// ProcessFileWithImports reads every file once anyway, the reader saves the reads across the calls
+func CachingReader(reader ReadFileFunc) ReadFileFunc {
+	if reader == nil {
+		reader = ioutil.ReadFile
+	}
+	var (
+		mu    sync.Mutex
+		cache = make(map[string][]byte)
+	)
+
+	return func(filename string) ([]byte, error) {
+		mu.Lock()
+		data, ok := cache[filename]
+		mu.Unlock()
+		if ok {
+			return data, nil
+		}
+		data, err := reader(filename)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		cache[filename] = data
+		mu.Unlock()
+
+		return data, nil
+	}
+}
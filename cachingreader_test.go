@@ -0,0 +1,55 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachingReader(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml":        []byte("imports:\n - {resource: conf.d/*.yml}\n - {resource: base.yml}\n - {resource: local.yml, ignore_errors: true}\nname: app"),
+		"base.yml":          []byte("imports:\n - {resource: conf.d/db.yml}\nname: base\nport: 80"),
+		"conf.d/db.yml":     []byte("db: localhost"),
+		"conf.d/server.yml": []byte("port: 8080"),
+	}
+	reads := map[string]int{}
+	reader := func(filename string) ([]byte, error) {
+		reads[filename]++
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+	var ts struct {
+		Name string
+		Port int
+		DB   string
+	}
+	glob := func(pattern string) ([]string, error) {
+		return []string{"conf.d/db.yml", "conf.d/server.yml"}, nil
+	}
+
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithGlob(glob))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, 80, ts.Port)
+	assert.Equal(t, "localhost", ts.DB)
+	assert.Equal(t, map[string]int{"config.yml": 1, "base.yml": 1, "conf.d/db.yml": 1, "conf.d/server.yml": 1, "local.yml": 1}, reads,
+		"every file is read once")
+
+	cached := CachingReader(reader)
+	for i := 0; i < 2; i++ {
+		err = ProcessFileWithImports("config.yml", &ts, WithReader(cached), WithGlob(glob))
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, map[string]int{"config.yml": 2, "base.yml": 2, "conf.d/db.yml": 2, "conf.d/server.yml": 2, "local.yml": 3}, reads,
+		"the files are read once across the calls, the failed reads are not cached")
+
+	files["local.yml"] = []byte("name: local")
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(cached), WithGlob(glob))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, 4, reads["local.yml"], "the missing file is read again once it appears")
+}
@@ -0,0 +1,109 @@
+package yaml
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// MergedChecksum processes config file and all it's imports tree, and returns hex SHA-256 of the merged config
+// The checksum depends only on the effective values, not on the files layout or the order of keys
+// ioutil.ReadFile is used if reader is nil
+func MergedChecksum(configPath string, reader ReadFileFunc) (string, error) {
+	var opts []Option
+	if reader != nil {
+		opts = append(opts, WithReader(reader))
+	}
+	merged, err := mergedTree(configPath, newOptions(opts))
+	if err != nil {
+		return "", err
+	}
+
+	return treeChecksum(merged), nil
+}
+
+// mergedTree loads config file and all it's imports tree into the internal generic destination, and returns
+// the merged tree. The hooks of dst, like validation and defaults, the result and the callbacks are off,
+// so they run only for the dst of the caller
+func mergedTree(configPath string, o *options) (map[interface{}]interface{}, error) {
+	probe := *o
+	probe.validate, probe.defaults, probe.result, probe.onIgnoredError = nil, nil, nil, nil
+	probe.nonEmptyValidation, probe.envBinding = false, false
+
+	return loadFile(configPath, new(interface{}), &probe)
+}
+
+// ProcessIfChanged processes config file and all it's imports tree into dst only if the merged config differs
+// from the one of prevChecksum, and returns the checksum of the merged config either way
+func ProcessIfChanged(configPath string, dst interface{}, prevChecksum string, opts ...Option) (changed bool, newChecksum string, err error) {
+	if err := checkDst(dst); err != nil {
+		return false, "", err
+	}
+
+	o := newOptions(opts)
+	merged, err := mergedTree(configPath, o)
+	if err != nil {
+		return false, "", err
+	}
+	if newChecksum = treeChecksum(merged); newChecksum == prevChecksum {
+		return false, newChecksum, nil
+	}
+
+	// the files could change since the first pass, the checksum must match the config loaded to dst
+	if merged, err = processFile(configPath, dst, o); err != nil {
+		return false, "", err
+	}
+
+	return true, treeChecksum(merged), nil
+}
+
+// treeChecksum returns hex SHA-256 of the canonical serialization of the tree
+func treeChecksum(tree map[interface{}]interface{}) string {
+	var buf bytes.Buffer
+	writeCanonical(&buf, tree)
+	sum := sha256.Sum256(buf.Bytes())
+
+	return hex.EncodeToString(sum[:])
+}
+
+// writeCanonical writes the unambiguous serialization of the tree with mapping keys sorted
+func writeCanonical(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		buf.WriteString(strconv.Quote(v))
+	case map[interface{}]interface{}:
+		entries := make([][2][]byte, 0, len(v))
+		for key, item := range v {
+			var k, i bytes.Buffer
+			writeCanonical(&k, key)
+			writeCanonical(&i, item)
+			entries = append(entries, [2][]byte{k.Bytes(), i.Bytes()})
+		}
+		sort.Slice(entries, func(a, b int) bool {
+			return bytes.Compare(entries[a][0], entries[b][0]) < 0
+		})
+		buf.WriteByte('{')
+		for _, entry := range entries {
+			buf.Write(entry[0])
+			buf.WriteByte(':')
+			buf.Write(entry[1])
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for _, item := range v {
+			writeCanonical(buf, item)
+			buf.WriteByte(',')
+		}
+		buf.WriteByte(']')
+	default:
+		// the type is a part of the value: 1 and 1.0 are different values
+		fmt.Fprintf(buf, "%T(%v)", v, v)
+	}
+}
@@ -0,0 +1,111 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergedChecksum(t *testing.T) {
+	files := map[string][]byte{
+		"layered.yml": []byte("imports:\n - {resource: db.yml}\n - {resource: limits.yml}\nname: app\ndb:\n  host: primary"),
+		"db.yml":      []byte("db:\n  host: localhost\n  port: 5432"),
+		"limits.yml":  []byte("limits: [1, 2.5, 'three']"),
+		"flat.yml":    []byte("limits:\n  - 1\n  - 2.5\n  - three\ndb: {port: 5432, host: primary}\nname: app"),
+		"other.yml":   []byte("limits: ['1', 2.5, three]\ndb: {port: 5432, host: primary}\nname: app"),
+	}
+	reader := mapReader(files)
+
+	layered, err := MergedChecksum("layered.yml", reader)
+	assert.Nil(t, err)
+	assert.Len(t, layered, 64)
+
+	flat, err := MergedChecksum("flat.yml", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, layered, flat)
+
+	other, err := MergedChecksum("other.yml", reader)
+	assert.Nil(t, err)
+	assert.NotEqual(t, layered, other, "string '1' differs from number 1")
+
+	_, err = MergedChecksum("missing.yml", reader)
+	assert.EqualError(t, err, "no such file")
+}
+
+func TestProcessIfChanged(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: db.yml}\nname: app"),
+		"db.yml":     []byte("db: {host: localhost}"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name string
+		DB   struct{ Host string }
+	}
+
+	var ts testStruct
+	changed, checksum, err := ProcessIfChanged("config.yml", &ts, "", WithReader(reader))
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "localhost", ts.DB.Host)
+	expected, _ := MergedChecksum("config.yml", reader)
+	assert.Equal(t, expected, checksum)
+
+	var unchanged testStruct
+	changed, newChecksum, err := ProcessIfChanged("config.yml", &unchanged, checksum, WithReader(reader))
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, checksum, newChecksum)
+	assert.Equal(t, testStruct{}, unchanged, "dst isn't changed")
+
+	files["db.yml"] = []byte("db: {host: db.prod}")
+	changed, newChecksum, err = ProcessIfChanged("config.yml", &ts, checksum, WithReader(reader))
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.NotEqual(t, checksum, newChecksum)
+	assert.Equal(t, "db.prod", ts.DB.Host)
+
+	_, _, err = ProcessIfChanged("missing.yml", &ts, checksum, WithReader(reader))
+	assert.EqualError(t, err, "no such file")
+}
+
+func TestProcessIfChangedHooks(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: optional.yml, ignore_errors: true}\nname: app"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name string
+	}
+	var (
+		validated []interface{}
+		ignored   []string
+		result    Result
+	)
+	opts := []Option{
+		WithReader(reader),
+		WithResult(&result),
+		WithValidate(func(dst interface{}) error {
+			validated = append(validated, dst)
+			return nil
+		}),
+		WithOnIgnoredError(func(resource string, err error) {
+			ignored = append(ignored, resource)
+		}),
+	}
+
+	var ts testStruct
+	changed, checksum, err := ProcessIfChanged("config.yml", &ts, "", opts...)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []interface{}{&ts}, validated, "only dst of the caller is validated")
+	assert.Equal(t, []string{"optional.yml"}, ignored, "the callbacks run once")
+	assert.Equal(t, []string{"config.yml"}, result.Loaded)
+
+	validated, ignored = nil, nil
+	changed, _, err = ProcessIfChanged("config.yml", &ts, checksum, opts...)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Nil(t, validated, "dst isn't loaded, so it isn't validated")
+	assert.Nil(t, ignored)
+}
@@ -0,0 +1,109 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	CoercionErr = errors.New("can't coerce value to field type")
+
+	durationType    = reflect.TypeOf(time.Duration(0))
+	unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
+
+// coerceTypes returns the transform converting string scalars to the types of dst fields they are decoded into,
+// so "8080" lands in int field and "yes" in bool one
+func coerceTypes(dst reflect.Type) nodeTransform {
+	return func(root *node, resource string) (bool, error) {
+		return walkScalars(root, dst, "", func(n *node, t reflect.Type, path string) (bool, error) {
+			return coerceNode(n, t, path, resource)
+		})
+	}
+}
+
+// coerceNode converts the string scalar decoded into the value of type t
+func coerceNode(n *node, t reflect.Type, path, resource string) (bool, error) {
+	if n.tag != strTag || t == durationType {
+		return false, nil
+	}
+	tag, text, err := coerceScalar(strings.TrimSpace(n.text), t)
+	if err != nil {
+		return false, fmt.Errorf("%s: line %d: key %s: %w: %q is not %s", resource, n.line, path, CoercionErr, n.text, t.Kind())
+	}
+	if tag == "" {
+		return false, nil
+	}
+	n.tag, n.text = tag, text
+
+	return true, nil
+}
+
+// coerceScalar converts the string to the tag and text of the plain scalar of type t
+// An empty tag is returned for the types which are not coerced
+func coerceScalar(s string, t reflect.Type) (tag, text string, err error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(s) {
+		case "true", "yes", "y", "on", "1":
+			return "!!bool", "true", nil
+		case "false", "no", "n", "off", "0":
+			return "!!bool", "false", nil
+		}
+		return "", "", CoercionErr
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, t.Bits())
+		return "!!int", strconv.FormatInt(i, 10), err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, t.Bits())
+		return "!!int", strconv.FormatUint(u, 10), err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		switch {
+		case math.IsInf(f, 1):
+			return "!!float", ".inf", err
+		case math.IsInf(f, -1):
+			return "!!float", "-.inf", err
+		case math.IsNaN(f):
+			return "!!float", ".nan", err
+		}
+		return "!!float", strconv.FormatFloat(f, 'g', -1, 64), err
+	}
+
+	return "", "", nil
+}
+
+// structFieldType finds the type of the struct field decoded from the key, inline fields included
+func structFieldType(t reflect.Type, key string) reflect.Type {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline, skip := yamlFieldKey(field)
+		if skip {
+			continue
+		}
+		if inline {
+			inlineType := field.Type
+			for inlineType.Kind() == reflect.Ptr {
+				inlineType = inlineType.Elem()
+			}
+			if inlineType.Kind() == reflect.Struct {
+				if found := structFieldType(inlineType, key); found != nil {
+					return found
+				}
+			}
+			continue
+		}
+		if name == key {
+			return field.Type
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTypeCoercion(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml":  []byte("imports:\n - {resource: server.yml}\nenabled: \"yes\"\nratio: '0.5'"),
+		"server.yml":  []byte("server:\n  port: \"8080\"\n  timeout: 5s\n  name: '42'\nlimits: {cpu: '2'}\nweights: ['1', ' 3 ']"),
+		"invalid.yml": []byte("enabled: true\nserver:\n  port: eighty"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Enabled bool
+		Ratio   float64
+		Server  struct {
+			Port    int
+			Timeout time.Duration
+			Name    string
+		}
+		Limits  map[string]uint
+		Weights []int8
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.NotNil(t, err)
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithTypeCoercion())
+	assert.Nil(t, err)
+	assert.True(t, ts.Enabled)
+	assert.Equal(t, 0.5, ts.Ratio)
+	assert.Equal(t, 8080, ts.Server.Port)
+	assert.Equal(t, 5*time.Second, ts.Server.Timeout)
+	assert.Equal(t, "42", ts.Server.Name)
+	assert.Equal(t, map[string]uint{"cpu": 2}, ts.Limits)
+	assert.Equal(t, []int8{1, 3}, ts.Weights)
+
+	err = ProcessFileWithImports("invalid.yml", &ts, WithReader(reader), WithTypeCoercion())
+	assert.True(t, errors.Is(err, CoercionErr))
+	assert.EqualError(t, err, "invalid.yml: line 3: key server.port: can't coerce value to field type: \"eighty\" is not int")
+}
@@ -0,0 +1,149 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var ContentTypeErr = errors.New("config file doesn't match the content type")
+
+// TypedReadFileFunc reads the config file like ReadFileFunc, and returns it's content type, as the readers of HTTP
+// resources can, so the files without extension are parsed right. Empty content type means YAML
+type TypedReadFileFunc func(filename string) (data []byte, contentType string, err error)
+
+// WithTypedReader sets the function used to read config file and all it's imports with their content types:
+// application/json and +json files are converted to YAML, other types are read as YAML
+// It replaces the reader set by WithReader or WithContextReader before it, the last of them is used
+func WithTypedReader(reader TypedReadFileFunc) Option {
+	return func(o *options) {
+		o.reader = func(filename string) ([]byte, error) {
+			data, contentType, err := reader(filename)
+			if err != nil {
+				return nil, err
+			}
+
+			return convertContent(filename, data, contentType)
+		}
+		o.contextReader = nil
+		o.customReader = true
+		o.typedReader = true
+	}
+}
+
+// isJSONFile checks if the resource is a JSON file by it's extension, the query of URL is ignored
+func isJSONFile(resource string) bool {
+	if isURL(resource) {
+		if u, err := url.Parse(resource); err == nil {
+			resource = u.Path
+		}
+	}
+
+	return strings.EqualFold(path.Ext(resource), ".json")
+}
+
+// convertJSONFile converts the file with .json extension to YAML, so it's imports are discovered and it's merged
+// the same way as YAML files. The content type of typed reader is used instead of the extension
+func (o *options) convertJSONFile(resource string, raw []byte) ([]byte, error) {
+	if o.typedReader || !isJSONFile(resource) {
+		return raw, nil
+	}
+	converted, err := jsonToYAML(raw)
+	if err != nil {
+		return nil, explainJSONError(resource, raw, err)
+	}
+
+	return converted, nil
+}
+
+// explainJSONError adds the line of the syntax error to the message
+func explainJSONError(resource string, raw []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Offset <= int64(len(raw)) {
+		return fmt.Errorf("%s:%d: %w", resource, bytes.Count(raw[:syntaxErr.Offset], []byte("\n"))+1, err)
+	}
+
+	return fmt.Errorf("%s: %w", resource, err)
+}
+
+// convertContent converts the file of the content type to YAML
+func convertContent(filename string, data []byte, contentType string) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return data, nil
+	}
+	converted, err := jsonToYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s: %v", filename, ContentTypeErr, mediaType, err)
+	}
+
+	return converted, nil
+}
+
+// jsonToYAML converts the JSON document to YAML, keeping the order of the keys
+func jsonToYAML(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeJSONValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the document")
+	}
+
+	return yaml.Marshal(value)
+}
+
+// decodeJSONValue decodes the next JSON value: objects are decoded to yaml.MapSlice to keep the order of the keys,
+// numbers are decoded to int64 if they are integers, to float64 otherwise
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			object := yaml.MapSlice{}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, yaml.MapItem{Key: key, Value: value})
+			}
+			_, err := decoder.Token()
+			return object, err
+		}
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+
+	return token, nil
+}
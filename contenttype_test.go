@@ -0,0 +1,113 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTypedReader(t *testing.T) {
+	type file struct {
+		data        string
+		contentType string
+	}
+	files := map[string]file{
+		"config.yml":                     {"imports:\n - {resource: https://config.example.com/app}\nname: app", ""},
+		"https://config.example.com/app": {`{"imports": [{"resource": "https://config.example.com/db"}], "port": 8080, "ratio": 0.5, "tags": ["a", "b"], "debug": true, "owner": null}`, "application/json; charset=utf-8"},
+		"https://config.example.com/db":  {"db:\n  host: localhost", "text/yaml"},
+		"broken.yml":                     {"imports:\n - {resource: broken.json}", ""},
+		"broken.json":                    {`{"port": 8080`, "application/problem+json"},
+	}
+	reader := func(filename string) ([]byte, string, error) {
+		if f, ok := files[filename]; ok {
+			return []byte(f.data), f.contentType, nil
+		}
+		return nil, "", errors.New("no such file")
+	}
+	var ts struct {
+		Name  string
+		Port  int
+		Ratio float64
+		Tags  []string
+		Debug bool
+		Owner *string
+		DB    struct{ Host string }
+	}
+
+	err := ProcessFileWithImports("config.yml", &ts, WithTypedReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, 8080, ts.Port)
+	assert.Equal(t, 0.5, ts.Ratio)
+	assert.Equal(t, []string{"a", "b"}, ts.Tags)
+	assert.True(t, ts.Debug)
+	assert.Nil(t, ts.Owner)
+	assert.Equal(t, "localhost", ts.DB.Host)
+
+	err = ProcessFileWithImports("broken.yml", &ts, WithTypedReader(reader))
+	assert.True(t, errors.Is(err, ContentTypeErr))
+
+	converted, err := jsonToYAML([]byte(`{"b": {"z": 1, "a": []}, "a": "{not: yaml}"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "b:\n  z: 1\n  a: []\na: '{not: yaml}'\n", string(converted), "the order of the keys is kept")
+	_, err = jsonToYAML([]byte(`{"a": 1} {"b": 2}`))
+	assert.NotNil(t, err)
+}
+
+func TestJSONFiles(t *testing.T) {
+	files := map[string]string{
+		"config.yml":   "imports:\n - {resource: db.json}\ndb:\n  name: app",
+		"db.json":      `{"imports": [{"resource": "defaults.yml"}], "db": {"host": "db.local", "port": 5432}}`,
+		"defaults.yml": "db:\n  host: localhost\n  user: admin",
+		"config.json":  `{"imports": [{"resource": "base.yml"}], "db": {"port": 6432}}`,
+		"base.yml":     "db:\n  host: localhost\n  port: 5432",
+		"broken.yml":   "imports:\n - {resource: broken.json}",
+		"broken.json":  "{\n  \"db\": {\"port\": 5432,}\n}",
+		"typed.json":   "db:\n  port: 5432",
+		"Upper.JSON":   `{"db": {"port": 5432}}`,
+	}
+	reader := func(filename string) ([]byte, error) {
+		if data, ok := files[filename]; ok {
+			return []byte(data), nil
+		}
+		return nil, errors.New("no such file")
+	}
+	type db struct {
+		Host string
+		Port int
+		User string
+		Name string
+	}
+	var ts struct{ DB db }
+
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, db{Host: "db.local", Port: 5432, User: "admin", Name: "app"}, ts.DB, "JSON import overrides the nested keys of it's imports")
+
+	ts.DB = db{}
+	err = ProcessFileWithImports("config.json", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, db{Host: "localhost", Port: 6432}, ts.DB, "JSON config imports YAML files")
+
+	ts.DB = db{}
+	err = ProcessFileWithImports("Upper.JSON", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, 5432, ts.DB.Port)
+
+	err = ProcessFileWithImports("broken.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ImportParseErr))
+	assert.Contains(t, err.Error(), "broken.yml -> broken.json:2: invalid character ','")
+
+	ts.DB = db{}
+	typed := func(filename string) ([]byte, string, error) {
+		data, err := reader(filename)
+		return data, "text/yaml", err
+	}
+	err = ProcessFileWithImports("typed.json", &ts, WithTypedReader(typed))
+	assert.Nil(t, err)
+	assert.Equal(t, 5432, ts.DB.Port, "content type is used instead of the extension")
+
+	assert.True(t, isJSONFile("https://config.example.com/db.json?version=2"))
+	assert.False(t, isJSONFile("https://config.example.com/db.json/app.yml"))
+}
@@ -0,0 +1,59 @@
+package yaml
+
+import (
+	"context"
+	"errors"
+)
+
+// ContextReadFileFunc reads the config file like ReadFileFunc, honoring the cancellation and the deadline of ctx
+type ContextReadFileFunc func(ctx context.Context, filename string) ([]byte, error)
+
+// ProcessFileWithImportsContext processes config file and all it's imports tree read with the reader,
+// ioutil.ReadFile if it's nil, and stops as soon as ctx is done, returning the error of ctx
+// The reader set by the options, like WithContextReader, takes precedence over the reader
+func ProcessFileWithImportsContext(ctx context.Context, configPath string, dst interface{}, reader ReadFileFunc, opts ...Option) error {
+	var readerOpts []Option
+	if reader != nil {
+		readerOpts = append(readerOpts, WithReader(reader))
+	}
+	opts = append(append(readerOpts, opts...), WithContext(ctx))
+
+	return ProcessFileWithImports(configPath, dst, opts...)
+}
+
+// WithContext makes processing stop as soon as ctx is done: the files are not read after that, and the read
+// in progress is left to finish in background. The imports ignoring errors don't ignore the error of ctx
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithContextReader sets the function used to read config file and all it's imports with the context
+// set by WithContext, context.Background() without it. It replaces the reader set by WithReader
+// or WithTypedReader before it, the last of them is used
+func WithContextReader(reader ContextReadFileFunc) Option {
+	return func(o *options) {
+		o.contextReader = reader
+		o.customReader = true
+		o.typedReader = false
+	}
+}
+
+// callReader reads the resource with the context reader if it's set, with the reader otherwise
+func (o *options) callReader(resource string) ([]byte, error) {
+	if o.contextReader == nil {
+		return o.reader(resource)
+	}
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return o.contextReader(ctx, resource)
+}
+
+// isCancelled checks if the error is caused by the context being done, such errors are never skipped
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
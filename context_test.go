@@ -0,0 +1,124 @@
+package yaml
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessFileWithImportsContext(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml":   []byte("imports:\n - {resource: db.yml}\n - {resource: cache.yml, ignore_errors: true}\nname: app"),
+		"db.yml":       []byte("imports:\n - {resource: defaults.yml}\ndb: {host: localhost}"),
+		"cache.yml":    []byte("cache: {ttl: 60}"),
+		"defaults.yml": []byte("name: default"),
+	}
+	var (
+		reads  []string
+		cancel = func() {}
+	)
+	reader := func(filename string) ([]byte, error) {
+		reads = append(reads, filename)
+		// the load is cancelled partway, while the second file is read
+		if filename == "cache.yml" {
+			cancel()
+		}
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+	var ts struct{ Name string }
+
+	err := ProcessFileWithImportsContext(context.Background(), "config.yml", &ts, reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+
+	reads = nil
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	cancel = cancelCtx
+	err = ProcessFileWithImportsContext(ctx, "config.yml", &ts, reader)
+	assert.True(t, errors.Is(err, context.Canceled), "the import ignoring errors doesn't ignore the cancellation")
+	assert.False(t, errors.Is(err, ImportNotFoundErr))
+	assert.Equal(t, []string{"config.yml", "cache.yml"}, reads, "no files are read after the cancellation")
+
+	// the hung read is abandoned at the deadline
+	hung := make(chan struct{})
+	defer close(hung)
+	start := time.Now()
+	deadline, cancelDeadline := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelDeadline()
+	err = ProcessFileWithImports("config.yml", &ts, WithContext(deadline), WithContextReader(func(ctx context.Context, filename string) ([]byte, error) {
+		if filename == "db.yml" {
+			<-hung
+		}
+		return files[filename], nil
+	}))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.EqualError(t, err, "db.yml: context deadline exceeded")
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	// the context reader gets the context
+	type key struct{}
+	valued := context.WithValue(context.Background(), key{}, "value")
+	err = ProcessFileWithImports("defaults.yml", &ts, WithContext(valued), WithContextReader(func(ctx context.Context, filename string) ([]byte, error) {
+		assert.Equal(t, "value", ctx.Value(key{}))
+		return files[filename], nil
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, "default", ts.Name)
+}
+
+func TestReaderPrecedence(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: db.json}\nname: app"),
+		"db.json":    []byte(`{"db": {"host": "localhost"}}`),
+	}
+	var used []string
+	reader := func(filename string) ([]byte, error) {
+		used = append(used, "reader")
+		return mapReader(files)(filename)
+	}
+	contextReader := func(ctx context.Context, filename string) ([]byte, error) {
+		used = append(used, "context reader")
+		return mapReader(files)(filename)
+	}
+	typedReader := func(filename string) ([]byte, string, error) {
+		used = append(used, "typed reader")
+		data, err := mapReader(files)(filename)
+		return data, "", err
+	}
+	type testStruct struct {
+		Name string
+		DB   struct{ Host string }
+	}
+
+	for name, tc := range map[string]struct {
+		process  func(dst interface{}) error
+		expected string
+	}{
+		"context reader over the reader argument": {func(dst interface{}) error {
+			return ProcessFileWithImportsContext(context.Background(), "config.yml", dst, reader, WithContextReader(contextReader))
+		}, "context reader"},
+		"context reader after typed reader": {func(dst interface{}) error {
+			return ProcessFileWithImports("config.yml", dst, WithTypedReader(typedReader), WithContextReader(contextReader))
+		}, "context reader"},
+		"reader after context reader": {func(dst interface{}) error {
+			return ProcessFileWithImports("config.yml", dst, WithContextReader(contextReader), WithReader(reader))
+		}, "reader"},
+		"typed reader after context reader": {func(dst interface{}) error {
+			return ProcessFileWithImports("config.yml", dst, WithContextReader(contextReader), WithTypedReader(typedReader))
+		}, "typed reader"},
+	} {
+		used = nil
+		var ts testStruct
+		err := tc.process(&ts)
+		assert.Nil(t, err, name)
+		assert.Equal(t, "localhost", ts.DB.Host, name)
+		assert.Equal(t, []string{tc.expected, tc.expected}, used, name)
+	}
+}
@@ -0,0 +1,67 @@
+package yaml
+
+import "reflect"
+
+// DeepCopy returns the copy of the loaded config sharing no maps, slices or pointers with src, so it can be handed out
+// as the snapshot which doesn't change when the config is loaded again into src. The copy has the same type as src,
+// the unexported fields of structs are copied as is. src must not contain reference cycles
+func DeepCopy(src interface{}) interface{} {
+	if src == nil {
+		return nil
+	}
+
+	return deepCopy(reflect.ValueOf(src)).Interface()
+}
+
+// deepCopy recursively copies the value, the unexported struct fields can't be set, they are copied shallowly
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		if v.Kind() == reflect.Ptr {
+			c := reflect.New(v.Type().Elem())
+			c.Elem().Set(deepCopy(v.Elem()))
+			return c
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for it := v.MapRange(); it.Next(); {
+			c.SetMapIndex(deepCopy(it.Key()), deepCopy(it.Value()))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	}
+
+	return v
+}
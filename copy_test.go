@@ -0,0 +1,47 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeepCopy(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: db.yml}\nname: app\ntags: [a, b]"),
+		"db.yml":     []byte("db:\n  host: localhost\n  ports: [5432]"),
+	}
+	reader := mapReader(files)
+
+	config := map[string]interface{}{}
+	err := ProcessFileWithImports("config.yml", &config, WithReader(reader))
+	assert.Nil(t, err)
+	snapshot := DeepCopy(config).(map[string]interface{})
+	assert.Equal(t, config, snapshot)
+
+	// the nested mappings of map dst are merged in place on reload
+	files["db.yml"] = []byte("db:\n  host: db.internal\n  ports: [6432]")
+	files["config.yml"] = []byte("imports:\n - {resource: db.yml}\nname: reloaded\ntags: [c]")
+	err = ProcessFileWithImports("config.yml", &config, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "db.internal", config["db"].(map[interface{}]interface{})["host"])
+	assert.Equal(t, map[string]interface{}{
+		"name": "app",
+		"tags": []interface{}{"a", "b"},
+		"db":   map[interface{}]interface{}{"host": "localhost", "ports": []interface{}{5432}},
+	}, snapshot)
+
+	type testStruct struct {
+		Name   string
+		Limits map[string]int
+		Owner  *struct{ Email string }
+		hidden []string
+	}
+	ts := &testStruct{Name: "app", Limits: map[string]int{"users": 10}, Owner: &struct{ Email string }{"ops@example.com"}, hidden: []string{"x"}}
+	copied := DeepCopy(ts).(*testStruct)
+	ts.Limits["users"], ts.Owner.Email = 20, "dev@example.com"
+	assert.Equal(t, 10, copied.Limits["users"])
+	assert.Equal(t, "ops@example.com", copied.Owner.Email)
+	assert.Equal(t, []string{"x"}, copied.hidden)
+	assert.Nil(t, DeepCopy(nil))
+}
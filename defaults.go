@@ -0,0 +1,50 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var DefaultsTypeErr = errors.New("defaults don't match the type of dst")
+
+// WithDefaults resets dst to the value returned by defaults before any file is loaded, so repeated loads into
+// the same dst start from the defaults instead of the values loaded before. The value is the one dst points to,
+// or a pointer to it. Like the values dst already has, the defaults are the lowest layer: the keys set by any file
+// win, even if they set zero values like false, the absent keys keep the defaults
+func WithDefaults(defaults func() interface{}) Option {
+	return func(o *options) {
+		o.defaults = defaults
+	}
+}
+
+// applyDefaults sets dst to the copy of the defaults, the internal generic destinations are kept as is
+func (o *options) applyDefaults(dst interface{}) error {
+	target := reflect.ValueOf(dst).Elem()
+	if o.defaults == nil || target.Kind() == reflect.Interface {
+		return nil
+	}
+
+	v := reflect.ValueOf(o.defaults())
+	if v.Kind() == reflect.Ptr && v.Type().Elem() == target.Type() {
+		if v.IsNil() {
+			return fmt.Errorf("%w: nil %s", DefaultsTypeErr, v.Type())
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Type() != target.Type() {
+		return fmt.Errorf("%w: %s expected, got %v", DefaultsTypeErr, target.Type(), typeName(v))
+	}
+	target.Set(reflect.ValueOf(DeepCopy(v.Interface())))
+
+	return nil
+}
+
+// typeName returns the type of the value, nil for the invalid value
+func typeName(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+
+	return v.Type().String()
+}
@@ -0,0 +1,55 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDefaults(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml":  []byte("imports:\n - {resource: service.yml}\nlabels:\n  env: prod"),
+		"service.yml": []byte("enabled: false\ntags: [service]"),
+		"timeout.yml": []byte("timeout: 10"),
+	}
+	reader := mapReader(files)
+	type config struct {
+		Enabled bool
+		Timeout int
+		Tags    []string
+		Labels  map[string]string
+	}
+	defaults := func() interface{} {
+		return config{Enabled: true, Timeout: 30, Tags: []string{"default"}, Labels: map[string]string{"team": "core", "env": "dev"}}
+	}
+	expected := config{Enabled: false, Timeout: 30, Tags: []string{"service"}, Labels: map[string]string{"team": "core", "env": "prod"}}
+
+	// the values of dst are the lowest layer: the keys set by the files win, the maps are merged, the slices replaced
+	ts := defaults().(config)
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, ts)
+
+	ts = config{}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithDefaults(defaults))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, ts)
+
+	err = ProcessFileWithImports("timeout.yml", &ts, WithReader(reader), WithDefaults(defaults))
+	assert.Nil(t, err)
+	assert.Equal(t, config{Enabled: true, Timeout: 10, Tags: []string{"default"}, Labels: map[string]string{"team": "core", "env": "dev"}}, ts,
+		"the values loaded before are reset")
+
+	shared := &config{Labels: map[string]string{"env": "dev"}}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithDefaults(func() interface{} { return shared }))
+	assert.Nil(t, err)
+	assert.Equal(t, "prod", ts.Labels["env"])
+	assert.Equal(t, "dev", shared.Labels["env"], "the defaults are copied")
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithDefaults(func() interface{} { return &ts.Labels }))
+	assert.True(t, errors.Is(err, DefaultsTypeErr))
+	assert.EqualError(t, err, "defaults don't match the type of dst: yaml.config expected, got *map[string]string")
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithDefaults(func() interface{} { return nil }))
+	assert.True(t, errors.Is(err, DefaultsTypeErr))
+}
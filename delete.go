@@ -0,0 +1,112 @@
+package yaml
+
+import (
+	"reflect"
+)
+
+// DeleteTag marks the keys removed from the config, like `debug: !delete`,
+// so a file can drop the value set by the files it imports instead of overriding it
+const DeleteTag = "!delete"
+
+// collectDeletions removes the keys tagged with DeleteTag from the mapping node tree, adding their paths to deleted
+func collectDeletions(n *node, path []interface{}, deleted *[][]interface{}) {
+	if n == nil || n.kind != mappingNode {
+		return
+	}
+
+	keys := n.keys[:0]
+	for _, key := range n.keys {
+		value := n.values[key]
+		keyPath := append(append([]interface{}{}, path...), key)
+		if value != nil && value.kind == scalarNode && value.tag == DeleteTag {
+			delete(n.values, key)
+			*deleted = append(*deleted, keyPath)
+			continue
+		}
+		collectDeletions(value, keyPath, deleted)
+		keys = append(keys, key)
+	}
+	n.keys = keys
+}
+
+// deleteTreePath removes the key at the path from the tree
+func deleteTreePath(tree map[interface{}]interface{}, path []interface{}) {
+	for _, key := range path[:len(path)-1] {
+		subtree, ok := tree[key].(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		tree = subtree
+	}
+	delete(tree, path[len(path)-1])
+}
+
+// deleteValuePath resets the field or removes the map key of the value at the path
+func deleteValuePath(v reflect.Value, path []interface{}) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	key := path[0]
+	switch v.Kind() {
+	case reflect.Struct:
+		name, ok := key.(string)
+		if !ok {
+			return
+		}
+		field, found := structField(v, name)
+		if !found {
+			return
+		}
+		if len(path) == 1 {
+			field.Set(reflect.Zero(field.Type()))
+			return
+		}
+		deleteValuePath(field, path[1:])
+	case reflect.Map:
+		k := reflect.ValueOf(key)
+		if !k.IsValid() || !k.Type().ConvertibleTo(v.Type().Key()) {
+			return
+		}
+		k = k.Convert(v.Type().Key())
+		if len(path) == 1 {
+			v.SetMapIndex(k, reflect.Value{})
+			return
+		}
+		if item := v.MapIndex(k); item.IsValid() {
+			// map items are not addressable, only the nested maps and pointers can be changed in place
+			deleteValuePath(item, path[1:])
+		}
+	}
+}
+
+// structField finds the settable struct field decoded from the key, inline fields included
+func structField(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, inline, skip := yamlFieldKey(t.Field(i))
+		if skip {
+			continue
+		}
+		field := v.Field(i)
+		if inline {
+			for field.Kind() == reflect.Ptr && !field.IsNil() {
+				field = field.Elem()
+			}
+			if field.Kind() == reflect.Struct {
+				if found, ok := structField(field, key); ok {
+					return found, true
+				}
+			}
+			continue
+		}
+		if name == key {
+			return field, true
+		}
+	}
+
+	return reflect.Value{}, false
+}
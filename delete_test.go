@@ -0,0 +1,57 @@
+package yaml
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestDeleteTag(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: defaults.yml}\n" +
+			"debug: !delete\nlimits:\n  cpu: !delete\nlabels:\n  team: !delete\n  owner: ops\ncache: !delete"),
+		"defaults.yml": []byte("debug: true\nname: app\nlimits:\n  cpu: 2\n  memory: 512\n" +
+			"labels:\n  team: core\n  tier: backend\ncache:\n  size: 10"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Debug  bool
+		Name   string
+		Limits struct{ CPU, Memory int }
+		Labels map[string]string
+		Cache  *struct{ Size int }
+	}
+
+	var ts testStruct
+	raw, err := ProcessFileWithRaw("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.False(t, ts.Debug)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, 0, ts.Limits.CPU)
+	assert.Equal(t, 512, ts.Limits.Memory)
+	assert.Equal(t, map[string]string{"tier": "backend", "owner": "ops"}, ts.Labels)
+	assert.Nil(t, ts.Cache)
+
+	var merged map[string]interface{}
+	assert.Nil(t, yaml.Unmarshal(raw, &merged))
+	assert.Equal(t, map[string]interface{}{
+		"name":   "app",
+		"limits": map[interface{}]interface{}{"memory": 512},
+		"labels": map[interface{}]interface{}{"tier": "backend", "owner": "ops"},
+	}, merged)
+
+	locs, err := SourceMap("config.yml", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"labels.owner", "labels.tier", "limits.memory", "name"}, sortedKeys(locs))
+}
+
+func sortedKeys(locs map[string]SourceLoc) []string {
+	keys := make([]string, 0, len(locs))
+	for key := range locs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,42 @@
+package yaml
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDepFile writes Make-style rule with the target depending on the config file and all it's imports,
+// so the build re-runs config compilation when any of the files changes
+// Imports which failed to load with ignore_errors are not listed, as there's no file to depend on
+// ioutil.ReadFile is used if reader is nil
+func WriteDepFile(configPath, target string, w io.Writer, reader ReadFileFunc) error {
+	var opts []Option
+	if reader != nil {
+		opts = append(opts, WithReader(reader))
+	}
+	importList, err := getReverseOrderedImports(configPath, newOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	var (
+		rule = []string{escapeMakePath(target) + ":"}
+		seen = make(map[string]bool)
+	)
+	for _, ci := range importList {
+		if ci.corrupted || seen[ci.Resource] {
+			continue
+		}
+		seen[ci.Resource] = true
+		rule = append(rule, escapeMakePath(ci.Resource))
+	}
+
+	_, err = fmt.Fprintln(w, strings.Join(rule, " "))
+	return err
+}
+
+// escapeMakePath escapes the characters with special meaning in Makefile rules
+func escapeMakePath(path string) string {
+	return strings.NewReplacer(" ", `\ `, "#", `\#`, "$", "$$").Replace(path)
+}
@@ -0,0 +1,32 @@
+package yaml
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDepFile(t *testing.T) {
+	files := map[string][]byte{
+		"config/config1.yml": []byte("imports:\n" +
+			" - {resource: config2.yml}\n" +
+			" - {resource: wrong_file.yml, ignore_errors: true}\n" +
+			" - {resource: my configs/local.yml}"),
+		"config/config2.yml":          []byte("imports:\n - {resource: subdir/config3.yml}"),
+		"config/subdir/config3.yml":   []byte("a: config3"),
+		"config/my configs/local.yml": []byte("a: local"),
+	}
+	reader := mapReader(files)
+
+	var buf bytes.Buffer
+	err := WriteDepFile("config/config1.yml", "build/config.json", &buf, reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "build/config.json: config/config1.yml config/my\\ configs/local.yml "+
+		"config/config2.yml config/subdir/config3.yml\n", buf.String())
+
+	buf.Reset()
+	err = WriteDepFile("config/missing.yml", "build/config.json", &buf, reader)
+	assert.NotNil(t, err)
+	assert.Equal(t, "", buf.String())
+}
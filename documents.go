@@ -0,0 +1,380 @@
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	NoMatchingDocumentErr = errors.New("no document matches the import selection")
+	ResourceKeyErr        = errors.New("resource_from_key must name a string or a list of strings")
+	UnwrapErr             = errors.New("imported config file can't be unwrapped")
+	// InvalidImportsErr is returned for the file which imports directives have the wrong shape,
+	// errors.As gets the underlying *yaml.TypeError
+	InvalidImportsErr = errors.New("imports must be a list of resources")
+
+	// typeErrTargetRe matches the internal type the value can't be decoded into, at the end of yaml.v2 type error
+	typeErrTargetRe = regexp.MustCompile(` into \S+$`)
+)
+
+// invalidImportsError is InvalidImportsErr of the file caused by the type error of the imports directives
+type invalidImportsError struct {
+	resource string
+	// key is the imports key, which is named in the message
+	key string
+	err *yaml.TypeError
+}
+
+// Error implements error, the messages of the type error are kept without the internal types
+func (e *invalidImportsError) Error() string {
+	messages := make([]string, 0, len(e.err.Errors))
+	for _, message := range e.err.Errors {
+		messages = append(messages, typeErrTargetRe.ReplaceAllString(message, ""))
+	}
+
+	return fmt.Sprintf("%s: %s must be a list of resources: %s", e.resource, e.key, strings.Join(messages, "; "))
+}
+
+// Unwrap returns the underlying type error
+func (e *invalidImportsError) Unwrap() error {
+	return e.err
+}
+
+// Is reports if the target is InvalidImportsErr
+func (e *invalidImportsError) Is(target error) bool {
+	return target == InvalidImportsErr
+}
+
+// splitDocuments splits multi-document YAML stream into separate documents
+// A line starting with "---" always starts a new document, even inside block scalar, so it's safe to split by lines
+// Comments and directives before the explicit document start belong to that document
+func splitDocuments(raw []byte) [][]byte {
+	documents, _ := splitDocumentLines(raw)
+
+	return documents
+}
+
+// splitDocumentLines splits the stream as splitDocuments does, and returns the number of lines before every document
+func splitDocumentLines(raw []byte) ([][]byte, []int) {
+	var (
+		documents [][]byte
+		lines     []int
+		start     int
+		// line is the number of the current line, startLine is the one of the current chunk
+		line, startLine int
+		// hasContent is set when the current chunk has anything besides comments and directives
+		hasContent bool
+	)
+
+	for offset := 0; offset < len(raw); line++ {
+		end := bytes.IndexByte(raw[offset:], '\n')
+		if end < 0 {
+			end = len(raw)
+		} else {
+			end += offset + 1
+		}
+		text := raw[offset:end]
+
+		if isDocumentStart(text) {
+			if hasContent {
+				documents, lines = append(documents, raw[start:offset]), append(lines, startLine)
+				start, startLine = offset, line
+			}
+			hasContent = true
+		} else if trimmed := bytes.TrimSpace(text); len(trimmed) > 0 && trimmed[0] != '#' && trimmed[0] != '%' {
+			hasContent = true
+		}
+		offset = end
+	}
+	if hasContent {
+		documents, lines = append(documents, raw[start:]), append(lines, startLine)
+	}
+
+	return documents, lines
+}
+
+func isDocumentStart(line []byte) bool {
+	if !bytes.HasPrefix(line, []byte("---")) {
+		return false
+	}
+	rest := line[3:]
+
+	return len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r' || rest[0] == '\n'
+}
+
+// selectDocuments returns the documents of the file chosen by document or match fields of the import
+// Without selection the whole file is returned, so only it's first document is used, as yaml.Unmarshal does
+func selectDocuments(raw []byte, ci configImport) ([][]byte, error) {
+	documents, _, err := selectDocumentLines(raw, ci)
+
+	return documents, err
+}
+
+// selectDocumentLines selects the documents as selectDocuments does, and returns the number of lines
+// in the file before every selected document
+func selectDocumentLines(raw []byte, ci configImport) ([][]byte, []int, error) {
+	if ci.Document == nil && ci.Match == nil {
+		return [][]byte{raw}, []int{0}, nil
+	}
+
+	documents, lines := splitDocumentLines(raw)
+	if ci.Document != nil {
+		if *ci.Document < 0 || *ci.Document >= len(documents) {
+			return nil, nil, fmt.Errorf("%s: document %d of %d: %w", ci.Resource, *ci.Document, len(documents), NoMatchingDocumentErr)
+		}
+		documents, lines = documents[*ci.Document:*ci.Document+1], lines[*ci.Document:*ci.Document+1]
+	}
+	if ci.Match != nil {
+		var (
+			matched      [][]byte
+			matchedLines []int
+		)
+		for i, document := range documents {
+			var fields map[string]interface{}
+			if err := yaml.Unmarshal(document, &fields); err != nil {
+				return nil, nil, err
+			}
+			if matchFields(fields, ci.Match) {
+				matched, matchedLines = append(matched, document), append(matchedLines, lines[i])
+			}
+		}
+		if len(matched) == 0 {
+			return nil, nil, fmt.Errorf("%s: match %v: %w", ci.Resource, ci.Match, NoMatchingDocumentErr)
+		}
+		documents, lines = matched, matchedLines
+	}
+
+	return documents, lines, nil
+}
+
+func matchFields(fields, match map[string]interface{}) bool {
+	for key, expected := range match {
+		if value, ok := fields[key]; !ok || !reflect.DeepEqual(value, expected) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decodeImports decodes the imports directives from the selected documents of the file, key is the imports key
+func decodeImports(raw []byte, ci configImport, key string) (configImports, error) {
+	var result configImports
+	documents, err := selectDocuments(raw, ci)
+	if err != nil {
+		return result, err
+	}
+	for _, document := range documents {
+		current, err := decodeImportsKey(document, key)
+		if err != nil {
+			var typeErr *yaml.TypeError
+			if errors.As(err, &typeErr) {
+				return result, &invalidImportsError{resource: ci.Resource, key: key, err: typeErr}
+			}
+			return result, err
+		}
+		imports, err := resourcesFromKeys(document, current.Imports)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", ci.Resource, err)
+		}
+		result.Imports = append(result.Imports, imports...)
+		required, err := resourcesFromKeys(document, current.RequiredImports)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", ci.Resource, err)
+		}
+		for _, importFile := range required {
+			importFile.IgnoreErrors = false
+			result.RequiredImports = append(result.RequiredImports, importFile)
+		}
+		result.InheritImports = result.InheritImports || current.InheritImports
+	}
+
+	return result, nil
+}
+
+// lazyValue keeps the value of the mapping to decode it later, once it's known which type it's decoded into
+type lazyValue struct {
+	unmarshal func(interface{}) error
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (v *lazyValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	v.unmarshal = unmarshal
+	return nil
+}
+
+// decodeImportsKey decodes the imports directives of the document with the imports key, the required and inherit
+// directives are named after it, like required_include and inherit_include for include key
+func decodeImportsKey(document []byte, key string) (configImports, error) {
+	var result configImports
+	if key == defaultImportsKey {
+		err := yaml.Unmarshal(document, &result)
+		return result, err
+	}
+
+	var fields map[interface{}]lazyValue
+	if err := yaml.Unmarshal(document, &fields); err != nil {
+		return result, err
+	}
+	directives := importsDirectives(key)
+	for i, dst := range []interface{}{&result.Imports, &result.RequiredImports, &result.InheritImports} {
+		if value, ok := fields[directives[i]]; ok {
+			if err := value.unmarshal(dst); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// resourcesFromKeys replaces the imports with resource_from_key by the imports of the resources listed
+// at that key of the document, the other fields of the import are kept
+func resourcesFromKeys(document []byte, imports []configImport) ([]configImport, error) {
+	var (
+		result []configImport
+		tree   map[interface{}]interface{}
+	)
+	for _, ci := range imports {
+		if ci.ResourceFromKey == "" {
+			result = append(result, ci)
+			continue
+		}
+		if tree == nil {
+			if err := yaml.Unmarshal(document, &tree); err != nil {
+				return nil, err
+			}
+		}
+
+		var value interface{} = tree
+		for _, key := range SplitKeyPath(ci.ResourceFromKey) {
+			m, ok := value.(map[interface{}]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = m[key]
+		}
+		var resources []interface{}
+		switch v := value.(type) {
+		case string:
+			resources = []interface{}{v}
+		case []interface{}:
+			resources = v
+		default:
+			return nil, fmt.Errorf("resource_from_key %s: %w", ci.ResourceFromKey, ResourceKeyErr)
+		}
+		for _, resource := range resources {
+			name, ok := resource.(string)
+			if !ok {
+				return nil, fmt.Errorf("resource_from_key %s: %w", ci.ResourceFromKey, ResourceKeyErr)
+			}
+			imported := ci
+			imported.Resource, imported.ResourceFromKey = name, ""
+			result = append(result, imported)
+		}
+	}
+
+	return result, nil
+}
+
+// unwrapDocuments replaces the documents with the mappings under their wrapper key: the dotted path of strip_prefix,
+// or the single top-level key with unwrap, the directives of the loader aren't counted as keys
+func unwrapDocuments(documents [][]byte, ci configImport, directives []string) ([][]byte, error) {
+	if ci.StripPrefix == "" && !ci.Unwrap {
+		return documents, nil
+	}
+
+	result := make([][]byte, 0, len(documents))
+	for _, document := range documents {
+		root, err := decodeNode(document)
+		if err != nil {
+			return nil, err
+		}
+		value, err := unwrapNode(root, ci, directives)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, encodeNode(value))
+	}
+
+	return result, nil
+}
+
+// unwrapNode returns the mapping under the wrapper key of the document root, the directives are removed from the root
+func unwrapNode(root *node, ci configImport, directives []string) (*node, error) {
+	if root == nil || root.kind != mappingNode {
+		return nil, fmt.Errorf("%s: %w: document is not a mapping", ci.Resource, UnwrapErr)
+	}
+
+	for _, directive := range directives {
+		root.removeKey(directive)
+	}
+	prefix := ci.StripPrefix
+	if prefix == "" {
+		if len(root.keys) != 1 {
+			return nil, fmt.Errorf("%s: %w: document has %d top-level keys", ci.Resource, UnwrapErr, len(root.keys))
+		}
+		prefix = joinKeyPath("", root.keys[0])
+	}
+	value := root
+	for _, key := range SplitKeyPath(prefix) {
+		if value.values[key] == nil || value.values[key].kind != mappingNode {
+			return nil, fmt.Errorf("%s: %w: %s is not a mapping", ci.Resource, UnwrapErr, prefix)
+		}
+		value = value.values[key]
+	}
+
+	return value, nil
+}
+
+// nestDocuments replaces the documents with the mappings putting them under the dotted path of the under field,
+// the directives of the loader are kept out. Documents without values are kept as is, so they don't reset the key
+func nestDocuments(documents [][]byte, ci configImport, directives []string) ([][]byte, error) {
+	if ci.Under == "" {
+		return documents, nil
+	}
+
+	result := make([][]byte, 0, len(documents))
+	for _, document := range documents {
+		root, err := decodeNode(document)
+		if err != nil {
+			return nil, err
+		}
+		if nested, ok := nestNode(root, ci, directives); ok {
+			document = encodeNode(nested)
+		}
+		result = append(result, document)
+	}
+
+	return result, nil
+}
+
+// nestNode puts the document root under the dotted path of the under field without the directives,
+// false is returned for the documents without values, they are kept as is
+func nestNode(root *node, ci configImport, directives []string) (*node, bool) {
+	if ci.Under == "" || root == nil {
+		return root, false
+	}
+	if root.kind == mappingNode {
+		for _, directive := range directives {
+			root.removeKey(directive)
+		}
+		if len(root.keys) == 0 {
+			return root, false
+		}
+	}
+
+	keys := SplitKeyPath(ci.Under)
+	for k := len(keys) - 1; k >= 0; k-- {
+		root = &node{kind: mappingNode, keys: []interface{}{keys[k]}, values: map[interface{}]*node{keys[k]: root}}
+	}
+
+	return root, true
+}
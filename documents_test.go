@@ -0,0 +1,196 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSplitDocuments(t *testing.T) {
+	testCases := []struct {
+		raw      string
+		expected []string
+	}{
+		{"a: 1", []string{"a: 1"}},
+		{"a: 1\n---\nb: 2\n", []string{"a: 1\n", "---\nb: 2\n"}},
+		{"# comment\n%YAML 1.1\n---\na: 1\n--- {b: 2}\n", []string{"# comment\n%YAML 1.1\n---\na: 1\n", "--- {b: 2}\n"}},
+		{"---\n---\na: 1", []string{"---\n", "---\na: 1"}},
+		{"a: |\n  text\n  ----\n---\nb: 2", []string{"a: |\n  text\n  ----\n", "---\nb: 2"}},
+		{"# just a comment\n", nil},
+	}
+
+	for _, tc := range testCases {
+		var documents []string
+		for _, document := range splitDocuments([]byte(tc.raw)) {
+			documents = append(documents, string(document))
+		}
+		assert.Equal(t, tc.expected, documents, tc.raw)
+	}
+}
+
+func TestDocumentSelection(t *testing.T) {
+	files := map[string][]byte{
+		"bundle.yml": []byte("kind: Deployment\nname: api\nreplicas: 3\n" +
+			"---\nkind: Service\nname: api-svc\nport: 80\n" +
+			"---\nkind: ConfigMap\nname: api-config\n"),
+		"by_index.yml": []byte("imports:\n - {resource: bundle.yml, document: 2}\n"),
+		"by_match.yml": []byte("imports:\n - {resource: bundle.yml, match: {kind: Service}}\n"),
+		"no_match.yml": []byte("imports:\n - {resource: bundle.yml, match: {kind: Secret}}\n"),
+		"no_match_ignored.yml": []byte("imports:\n" +
+			" - {resource: bundle.yml, match: {kind: Secret}, ignore_errors: true}\nname: base\n"),
+		"out_of_range.yml": []byte("imports:\n - {resource: bundle.yml, document: 3}\n"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Kind     string
+		Name     string
+		Replicas int
+		Port     int
+	}
+
+	testCases := []struct {
+		testFile      string
+		expected      testStruct
+		expectedError bool
+	}{
+		{"by_index.yml", testStruct{Kind: "ConfigMap", Name: "api-config"}, false},
+		{"by_match.yml", testStruct{Kind: "Service", Name: "api-svc", Port: 80}, false},
+		{"no_match.yml", testStruct{}, true},
+		{"no_match_ignored.yml", testStruct{Name: "base"}, false},
+		{"out_of_range.yml", testStruct{}, true},
+	}
+
+	for _, tc := range testCases {
+		var ts testStruct
+		err := ProcessFileWithImports(tc.testFile, &ts, WithReader(reader))
+		if tc.expectedError {
+			assert.True(t, errors.Is(err, NoMatchingDocumentErr), tc.testFile)
+		} else {
+			assert.Nil(t, err, tc.testFile)
+		}
+		assert.Equal(t, tc.expected, ts, tc.testFile)
+	}
+}
+
+func TestUnwrapImports(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n" +
+			" - {resource: database.yml, unwrap: true}\n" +
+			" - {resource: services.yml, strip_prefix: shared.cache}\n" +
+			"name: app"),
+		"database.yml": []byte("imports:\n - {resource: defaults.yml}\ndatabase:\n  host: localhost\n  port: 5432"),
+		"defaults.yml": []byte("host: default"),
+		"services.yml": []byte("shared:\n  cache:\n    ttl: 60\n  other: dropped\nname: dropped"),
+		"two_keys.yml": []byte("imports:\n - {resource: services.yml, unwrap: true}"),
+		"scalar.yml":   []byte("imports:\n - {resource: services.yml, strip_prefix: shared.other}"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		Name, Host string
+		Port, TTL  int
+	}
+
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "localhost", ts.Host, "the wrapped content overrides the imports of the wrapped file")
+	assert.Equal(t, 5432, ts.Port)
+	assert.Equal(t, 60, ts.TTL)
+
+	err = ProcessFileWithImports("two_keys.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, UnwrapErr))
+	assert.EqualError(t, err, "two_keys.yml -> services.yml: imported config file can't be unwrapped: document has 2 top-level keys")
+	err = ProcessFileWithImports("scalar.yml", &ts, WithReader(reader))
+	assert.EqualError(t, err, "scalar.yml -> services.yml: imported config file can't be unwrapped: shared.other is not a mapping")
+}
+
+func TestNestedImports(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n" +
+			" - {resource: db.yml, under: database}\n" +
+			" - {resource: cache.yml, under: services.cache}\n" +
+			" - {resource: empty.yml, under: database}\n" +
+			"name: app\ndatabase:\n  name: app"),
+		"db.yml":          []byte("imports:\n - {resource: db_defaults.yml}\nhost: localhost\nport: 5432"),
+		"db_defaults.yml": []byte("database:\n  host: default\n  user: admin"),
+		"cache.yml":       []byte("ttl: 60"),
+		"empty.yml":       []byte("imports: []"),
+		"wrapped.yml":     []byte("imports:\n - {resource: services.yml, strip_prefix: shared, under: services.cache}"),
+		"services.yml":    []byte("shared:\n  ttl: 30"),
+	}
+	reader := mapReader(files)
+	type config struct {
+		Name     string
+		Database struct {
+			Host, User, Name string
+			Port             int
+		}
+		Services struct {
+			Cache struct{ TTL int }
+		}
+	}
+
+	var ts config
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithStrict())
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "localhost", ts.Database.Host, "the file is loaded under the key")
+	assert.Equal(t, 5432, ts.Database.Port)
+	assert.Equal(t, "admin", ts.Database.User, "the imports of the nested file are loaded at the root")
+	assert.Equal(t, "app", ts.Database.Name)
+	assert.Equal(t, 60, ts.Services.Cache.TTL, "the file is loaded under the dotted path")
+
+	ts = config{}
+	err = ProcessFileWithImports("wrapped.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, 30, ts.Services.Cache.TTL, "the prefix is stripped before nesting")
+
+	files["replicas.yml"] = []byte("imports:\n - {resource: host.yml, under: primary}\n - {resource: host.yml, under: replica}")
+	files["host.yml"] = []byte("host: h")
+	replicas := map[string]interface{}{}
+	err = ProcessFileWithImports("replicas.yml", &replicas, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"primary": map[interface{}]interface{}{"host": "h"},
+		"replica": map[interface{}]interface{}{"host": "h"},
+	}, replicas, "the file nested under different keys is imported once for every key")
+
+	files["replicas.yml"] = []byte("imports:\n - {resource: primary.yml}\n - {resource: replica.yml}")
+	files["primary.yml"] = []byte("imports:\n - {resource: host.yml, under: primary}")
+	files["replica.yml"] = []byte("imports:\n - {resource: host.yml, under: replica}")
+	replicas = map[string]interface{}{}
+	err = ProcessFileWithImports("replicas.yml", &replicas, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"primary": map[interface{}]interface{}{"host": "h"},
+		"replica": map[interface{}]interface{}{"host": "h"},
+	}, replicas, "the imports of several files nesting the file under different keys are kept")
+}
+
+func TestInvalidImports(t *testing.T) {
+	files := map[string][]byte{
+		"list.yml":    []byte("[imports, defaults.yml]"),
+		"mapping.yml": []byte("imports:\n - {resource: nested.yml}"),
+		"nested.yml":  []byte("imports:\n  resource: defaults.yml"),
+		"item.yml":    []byte("imports:\n - [defaults.yml]"),
+	}
+	reader := mapReader(files)
+	var ts struct{ Name string }
+
+	err := ProcessFileWithImports("list.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, InvalidImportsErr))
+	assert.EqualError(t, err, "list.yml: imports must be a list of resources: line 1: cannot unmarshal !!seq")
+	var typeErr *yaml.TypeError
+	assert.True(t, errors.As(err, &typeErr), "the underlying error is kept")
+
+	err = ProcessFileWithImports("mapping.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, InvalidImportsErr))
+	assert.True(t, errors.Is(err, ImportParseErr))
+	assert.EqualError(t, err, "mapping.yml -> nested.yml: imports must be a list of resources: line 2: cannot unmarshal !!map")
+
+	err = ProcessFileWithImports("item.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, InvalidImportsErr))
+	assert.EqualError(t, err, "item.yml: imports must be a list of resources: line 2: cannot unmarshal !!seq")
+}
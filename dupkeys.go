@@ -0,0 +1,61 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+var DuplicateKeyErr = errors.New("mapping key is duplicated")
+
+// WithDuplicateKeysCheck fails processing with DuplicateKeyErr naming the file and the dotted path of the key
+// if any mapping of the file repeats the key. yaml.v2 keeps the last value of the repeated keys silently
+func WithDuplicateKeysCheck() Option {
+	return func(o *options) {
+		o.duplicateKeysCheck = true
+	}
+}
+
+// checkDuplicateKeys returns DuplicateKeyErr for the first repeated key of the mapping document. The documents which
+// aren't mappings are left to the decoding, which reports their errors
+func checkDuplicateKeys(resource string, document []byte) error {
+	var root yaml.MapSlice
+	if err := yaml.Unmarshal(document, &root); err != nil {
+		return nil
+	}
+	if path, ok := duplicateKey(root, ""); ok {
+		return fmt.Errorf("%s: key %s: %w", resource, path, DuplicateKeyErr)
+	}
+
+	return nil
+}
+
+// duplicateKey returns the dotted path of the first repeated key of the value, the nested mappings of yaml.MapSlice
+// are decoded as yaml.MapSlice too, the items of sequences are named by their indexes
+func duplicateKey(value interface{}, path string) (string, bool) {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		seen := make(map[string]bool, len(v))
+		for _, item := range v {
+			key := joinKeyPath(path, item.Key)
+			// the complex keys like [a, b] can't be map keys, so the keys are compared by their typed representation
+			id := fmt.Sprintf("%T %#v", item.Key, item.Key)
+			if seen[id] {
+				return key, true
+			}
+			seen[id] = true
+			if duplicate, ok := duplicateKey(item.Value, key); ok {
+				return duplicate, true
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if duplicate, ok := duplicateKey(item, joinKeyPath(path, i)); ok {
+				return duplicate, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,58 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDuplicateKeysCheck(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml":   []byte("imports:\n - {resource: top.yml}\nname: app"),
+		"top.yml":      []byte("database:\n  host: localhost\nname: top\ndatabase:\n  host: db.local"),
+		"nested.yml":   []byte("imports:\n - {resource: db.yml}"),
+		"db.yml":       []byte("database:\n  host: localhost\n  port: 5432\n  host: db.local"),
+		"items.yml":    []byte("servers:\n - {name: a, port: 80}\n - {name: b, name: c}"),
+		"dotted.yml":   []byte("labels:\n  app.name: app\n  app.name: other"),
+		"valid.yml":    []byte("imports:\n - {resource: db_valid.yml}\ndatabase:\n  host: override"),
+		"db_valid.yml": []byte("database:\n  host: localhost\n  port: 5432"),
+		"ignored.yml":  []byte("imports:\n - {resource: db.yml, ignore_errors: true}\nname: ignored"),
+	}
+	reader := mapReader(files)
+	var ts map[string]interface{}
+
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err, "the last value wins by default")
+	assert.Equal(t, map[interface{}]interface{}{"host": "db.local"}, ts["database"])
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithDuplicateKeysCheck())
+	assert.True(t, errors.Is(err, DuplicateKeyErr))
+	assert.True(t, errors.Is(err, ImportParseErr))
+	assert.EqualError(t, err, "config.yml -> top.yml: key database: mapping key is duplicated")
+
+	err = ProcessFileWithImports("nested.yml", &ts, WithReader(reader), WithDuplicateKeysCheck())
+	assert.EqualError(t, err, "nested.yml -> db.yml: key database.host: mapping key is duplicated")
+	err = ProcessFileWithImports("items.yml", &ts, WithReader(reader), WithDuplicateKeysCheck())
+	assert.EqualError(t, err, "items.yml: key servers.1.name: mapping key is duplicated")
+	err = ProcessFileWithImports("dotted.yml", &ts, WithReader(reader), WithDuplicateKeysCheck())
+	assert.EqualError(t, err, `dotted.yml: key labels.app\.name: mapping key is duplicated`)
+
+	ts = nil
+	err = ProcessFileWithImports("valid.yml", &ts, WithReader(reader), WithDuplicateKeysCheck())
+	assert.Nil(t, err, "the keys overridden by other files are not duplicates")
+	assert.Equal(t, map[interface{}]interface{}{"host": "override", "port": 5432}, ts["database"])
+
+	err = ProcessFileWithImports("ignored.yml", &ts, WithReader(reader), WithDuplicateKeysCheck())
+	assert.Nil(t, err, "the import with duplicates ignores errors")
+	assert.Equal(t, "ignored", ts["name"])
+
+	files["complex.yml"] = []byte("? [a, b]\n: 1\n")
+	plainErr := ProcessFileWithImports("complex.yml", &ts, WithReader(reader))
+	assert.NotNil(t, plainErr)
+	err = ProcessFileWithImports("complex.yml", &ts, WithReader(reader), WithDuplicateKeysCheck())
+	assert.Equal(t, plainErr, err, "the complex keys are left to the decoding")
+	files["complex.yml"] = []byte("? [a, b]\n: 1\n? [a, b]\n: 2\n1: int\n1.0: float\n")
+	err = ProcessFileWithImports("complex.yml", &ts, WithReader(reader), WithDuplicateKeysCheck())
+	assert.EqualError(t, err, "complex.yml: key [a b]: mapping key is duplicated")
+}
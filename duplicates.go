@@ -0,0 +1,94 @@
+package yaml
+
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FindDuplicateFragments discovers the imports tree of the config file and groups the files with identical
+// parsed content, regardless of formatting, comments and the order of keys. Only the documents selected by
+// the import are compared, after unwrap, strip_prefix and under are applied, so the file imported with the different
+// selections can be listed several times. Only the groups of two or more files are returned, in the order
+// of imports discovery. Files which fail to load with ignore_errors are skipped
+// ioutil.ReadFile is used if reader is nil
+func FindDuplicateFragments(configPath string, reader ReadFileFunc) ([][]string, error) {
+	var opts []Option
+	if reader != nil {
+		opts = append(opts, WithReader(reader))
+	}
+	o := newOptions(opts)
+	importList, err := getReverseOrderedImports(configPath, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		groups [][]string
+		// group is the index of the group of the files with the same canonical content
+		group = make(map[string]int)
+		seen  = make(map[string]bool)
+	)
+	for _, ci := range importList {
+		key := o.importKey(ci)
+		if ci.corrupted || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		raw, err := o.read(ci)
+		if err != nil {
+			return nil, err
+		}
+		tree, err := selectedTree(raw, ci, o)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		writeCanonical(&buf, tree)
+		if i, ok := group[buf.String()]; ok {
+			groups[i] = append(groups[i], ci.Resource)
+			continue
+		}
+		group[buf.String()] = len(groups)
+		groups = append(groups, []string{ci.Resource})
+	}
+
+	var duplicates [][]string
+	for _, files := range groups {
+		if len(files) > 1 {
+			duplicates = append(duplicates, files)
+		}
+	}
+
+	return duplicates, nil
+}
+
+// selectedTree returns the merged content of the documents of the file selected by the import
+func selectedTree(raw []byte, ci configImport, o *options) (map[interface{}]interface{}, error) {
+	documents, err := selectDocuments(raw, ci)
+	if err == nil {
+		documents, err = unwrapDocuments(documents, ci, o.directives())
+	}
+	if err == nil {
+		documents, err = nestDocuments(documents, ci, o.directives())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[interface{}]interface{}
+	for _, document := range documents {
+		var documentTree map[interface{}]interface{}
+		if err := yaml.Unmarshal(document, &documentTree); err != nil {
+			return nil, err
+		}
+		if tree == nil {
+			tree = documentTree
+			continue
+		}
+		mergeTree(tree, documentTree)
+	}
+
+	return tree, nil
+}
@@ -0,0 +1,52 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDuplicateFragments(t *testing.T) {
+	files := map[string][]byte{
+		"app.yml": []byte("imports:\n - {resource: db.yml}\n - {resource: db_copy.yml}\n - {resource: cache.yml}\n" +
+			" - {resource: missing.yml, ignore_errors: true}\nname: app"),
+		"db.yml":      []byte("db:\n  host: localhost\n  port: 5432\n"),
+		"db_copy.yml": []byte("# copied from db.yml\ndb: {port: 5432, host: 'localhost'}"),
+		"cache.yml":   []byte("db:\n  host: localhost\n  port: '5432'\n"),
+	}
+	reader := mapReader(files)
+
+	groups, err := FindDuplicateFragments("app.yml", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"db_copy.yml", "db.yml"}}, groups, "string '5432' differs from number 5432")
+
+	files["cache.yml"] = []byte("imports:\n - {resource: db.yml}")
+	groups, err = FindDuplicateFragments("app.yml", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"db_copy.yml", "db.yml"}}, groups, "repeated import isn't a duplicate")
+
+	groups, err = FindDuplicateFragments("db.yml", reader)
+	assert.Nil(t, err)
+	assert.Nil(t, groups)
+
+	_, err = FindDuplicateFragments("missing.yml", reader)
+	assert.EqualError(t, err, "missing.yml: no such file")
+}
+
+func TestFindDuplicateFragmentsSelection(t *testing.T) {
+	files := map[string][]byte{
+		"app.yml": []byte("imports:\n - {resource: db.yml}\n - {resource: bundle.yml, document: 1}\n" +
+			" - {resource: bundle.yml, document: 0}\n - {resource: wrapped.yml, unwrap: true}\n" +
+			" - {resource: host.yml, under: db}\nname: app"),
+		"db.yml":      []byte("db:\n  host: localhost\n  port: 5432\n"),
+		"bundle.yml":  []byte("cache: redis\n---\ndb: {host: localhost, port: 5432}\n"),
+		"wrapped.yml": []byte("production:\n  db: {host: localhost, port: 5432}\n"),
+		"host.yml":    []byte("host: localhost\nport: 5432\n"),
+	}
+	reader := mapReader(files)
+
+	groups, err := FindDuplicateFragments("app.yml", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"host.yml", "wrapped.yml", "bundle.yml", "db.yml"}}, groups,
+		"only the selected documents are compared, with their wrapper keys applied")
+}
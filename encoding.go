@@ -0,0 +1,76 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Encoding is the character encoding of config files
+type Encoding int
+
+const (
+	// EncodingAuto detects UTF-16 files by the byte order mark, the other files are read as UTF-8
+	EncodingAuto Encoding = iota
+	EncodingUTF8
+	EncodingUTF16LE
+	EncodingUTF16BE
+)
+
+var (
+	EncodingErr = errors.New("config file doesn't match the encoding")
+
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// decodeText decodes the file content to UTF-8 without the byte order mark
+func decodeText(resource string, raw []byte, enc Encoding) ([]byte, error) {
+	if enc == EncodingAuto {
+		switch {
+		case bytes.HasPrefix(raw, utf16LEBOM):
+			enc = EncodingUTF16LE
+		case bytes.HasPrefix(raw, utf16BEBOM):
+			enc = EncodingUTF16BE
+		default:
+			enc = EncodingUTF8
+		}
+	}
+
+	switch enc {
+	case EncodingUTF16LE:
+		return decodeUTF16(resource, bytes.TrimPrefix(raw, utf16LEBOM), binary.LittleEndian)
+	case EncodingUTF16BE:
+		return decodeUTF16(resource, bytes.TrimPrefix(raw, utf16BEBOM), binary.BigEndian)
+	}
+
+	return bytes.TrimPrefix(raw, utf8BOM), nil
+}
+
+// decodeUTF16 decodes UTF-16 content with code units in the byte order, the unpaired surrogates are reported
+// with EncodingErr instead of being replaced
+func decodeUTF16(resource string, raw []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(raw)%2 != 0 {
+		return nil, fmt.Errorf("%s: %w: odd number of bytes in UTF-16", resource, EncodingErr)
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = order.Uint16(raw[2*i:])
+	}
+	for i := 0; i < len(units); i++ {
+		if !utf16.IsSurrogate(rune(units[i])) {
+			continue
+		}
+		if i+1 < len(units) && utf16.DecodeRune(rune(units[i]), rune(units[i+1])) != unicode.ReplacementChar {
+			i++
+			continue
+		}
+		return nil, fmt.Errorf("%s: %w: unpaired UTF-16 surrogate at byte %d", resource, EncodingErr, 2*i)
+	}
+
+	return []byte(string(utf16.Decode(units))), nil
+}
@@ -0,0 +1,88 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEncoding(t *testing.T) {
+	utf16LE := func(s string, bom bool) []byte {
+		var raw []byte
+		if bom {
+			raw = append(raw, 0xFF, 0xFE)
+		}
+		for _, u := range utf16.Encode([]rune(s)) {
+			raw = append(raw, byte(u), byte(u>>8))
+		}
+		return raw
+	}
+	files := map[string][]byte{
+		"app.yml":   []byte("\xEF\xBB\xBFimports:\n - {resource: de.yml}\n - {resource: ru.yml}\nname: app"),
+		"de.yml":    utf16LE("greeting: Grüß Gott\nimports:\n - {resource: plain.yml}\n", true),
+		"ru.yml":    []byte("\xFE\xFF\x00c\x00:\x00 \x04\x1F"),
+		"plain.yml": []byte("db: {host: localhost}"),
+		"nobom.yml": utf16LE("name: nobom", false),
+		"odd.yml":   utf16LE("name: odd", true)[:5],
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name     string
+		Greeting string
+		C        string
+		DB       struct{ Host string }
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("app.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "Grüß Gott", ts.Greeting)
+	assert.Equal(t, "П", ts.C)
+	assert.Equal(t, "localhost", ts.DB.Host)
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("nobom.yml", &ts, WithReader(reader), WithEncoding(EncodingUTF16LE))
+	assert.Nil(t, err)
+	assert.Equal(t, "nobom", ts.Name)
+
+	err = ProcessFileWithImports("odd.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, EncodingErr))
+	assert.EqualError(t, err, "odd.yml: config file doesn't match the encoding: odd number of bytes in UTF-16")
+}
+
+func TestUTF16SurrogatePairs(t *testing.T) {
+	utf16BE := func(s string) []byte {
+		raw := []byte{0xFE, 0xFF}
+		for _, u := range utf16.Encode([]rune(s)) {
+			raw = append(raw, byte(u>>8), byte(u))
+		}
+		return raw
+	}
+	files := map[string][]byte{
+		"emoji.yml":    utf16BE("name: 😀 𝄞"),
+		"explicit.yml": utf16BE("name: explicit 😀"),
+		// the high surrogate of 😀 without the low one
+		"unpaired.yml": append(utf16BE("name: "), 0xD8, 0x3D, 0x00, 0x21),
+		"trailing.yml": append(utf16BE("name: "), 0xD8, 0x3D),
+	}
+	reader := mapReader(files)
+	var ts struct{ Name string }
+
+	err := ProcessFileWithImports("emoji.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "😀 𝄞", ts.Name)
+
+	err = ProcessFileWithImports("explicit.yml", &ts, WithReader(reader), WithEncoding(EncodingUTF16BE))
+	assert.Nil(t, err)
+	assert.Equal(t, "explicit 😀", ts.Name, "the byte order mark is skipped with the explicit encoding")
+
+	err = ProcessFileWithImports("unpaired.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, EncodingErr))
+	assert.EqualError(t, err, "unpaired.yml: config file doesn't match the encoding: unpaired UTF-16 surrogate at byte 12")
+
+	err = ProcessFileWithImports("trailing.yml", &ts, WithReader(reader))
+	assert.EqualError(t, err, "trailing.yml: config file doesn't match the encoding: unpaired UTF-16 surrogate at byte 12")
+}
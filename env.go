@@ -0,0 +1,228 @@
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	UndefinedVariableErr = errors.New("undefined environment variable")
+
+	// envVariableRe matches ${NAME} and ${NAME:-default} references, bare $NAME ones, and $$ escaping the dollar sign
+	envVariableRe = regexp.MustCompile(`\$\$|\$(?:\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+	// blockScalarRe matches the end of the line starting a block scalar, like `script: |` or `- >-`
+	blockScalarRe = regexp.MustCompile(`(?:^|[ \t])[|>][-+0-9]*$`)
+)
+
+// substituteEnv replaces $NAME, ${NAME} and ${NAME:-default} references in the raw file with the values of variables
+// returned by lookup. Undefined variables without default are replaced with empty string, and returned as the second value
+// $$ is replaced with a single dollar sign, so $$NAME is kept as the literal $NAME
+// The comments are kept as is, the variables they mention aren't looked up
+func substituteEnv(raw []byte, lookup func(name string) (string, bool)) ([]byte, []string) {
+	var (
+		undefined []string
+		result    []byte
+		start     int
+	)
+	for _, comment := range commentRanges(raw) {
+		result = append(result, substituteReferences(raw[start:comment[0]], lookup, &undefined)...)
+		result = append(result, raw[comment[0]:comment[1]]...)
+		start = comment[1]
+	}
+	result = append(result, substituteReferences(raw[start:], lookup, &undefined)...)
+
+	return result, undefined
+}
+
+// substituteReferences replaces the references in the text as substituteEnv does, adding the undefined variables
+func substituteReferences(text []byte, lookup func(name string) (string, bool), undefined *[]string) []byte {
+	return envVariableRe.ReplaceAllFunc(text, func(reference []byte) []byte {
+		if string(reference) == "$$" {
+			return []byte("$")
+		}
+		match := envVariableRe.FindSubmatch(reference)
+		name := string(match[1])
+		if match[4] != nil {
+			name = string(match[4])
+		}
+		value, ok := lookup(name)
+		if match[2] != nil && value == "" {
+			return match[3]
+		}
+		if !ok {
+			*undefined = append(*undefined, name)
+		}
+		return []byte(value)
+	})
+}
+
+// commentRanges returns the start and end offsets of the comments of YAML text
+// The # signs of quoted scalars, including the multi-line ones, and of block scalars don't start comments
+func commentRanges(raw []byte) [][2]int {
+	var (
+		ranges [][2]int
+		// quote is the quote of the scalar continued from the previous line, 0 if there is none
+		quote byte
+		// blockIndent is the indentation of the line starting the current block scalar, -1 outside of them
+		blockIndent = -1
+	)
+	for offset := 0; offset < len(raw); {
+		end := bytes.IndexByte(raw[offset:], '\n')
+		if end < 0 {
+			end = len(raw)
+		} else {
+			end += offset
+		}
+		line := raw[offset:end]
+		next := end + 1
+		indent := len(line) - len(bytes.TrimLeft(line, " "))
+		if blockIndent >= 0 {
+			// the lines of a block scalar are indented more than the line starting it
+			if len(bytes.TrimSpace(line)) == 0 || indent > blockIndent {
+				offset = next
+				continue
+			}
+			blockIndent = -1
+		}
+
+		comment := -1
+		for i := 0; i < len(line) && comment < 0; i++ {
+			c := line[i]
+			switch {
+			case quote == '"' && c == '\\':
+				i++
+			case quote == '\'' && c == '\'' && i+1 < len(line) && line[i+1] == '\'':
+				i++
+			case quote != 0 && c == quote:
+				quote = 0
+			case quote != 0:
+			case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+				comment = i
+			case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,", line[i-1]) >= 0):
+				quote = c
+			}
+		}
+		content := line
+		if comment >= 0 {
+			ranges = append(ranges, [2]int{offset + comment, end})
+			content = line[:comment]
+		}
+		if quote == 0 && blockScalarRe.Match(bytes.TrimRight(content, " \t\r")) {
+			blockIndent = indent
+		}
+		offset = next
+	}
+
+	return ranges
+}
+
+// expand applies the substitution of environment variables to the raw file if it's enabled
+func (o *options) expand(raw []byte) ([]byte, []string) {
+	if !o.envSubstitution {
+		return raw, nil
+	}
+	lookup := o.envLookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	return substituteEnv(raw, lookup)
+}
+
+// undefinedVariables collects undefined variables referenced across the imports tree, to report them all at once
+type undefinedVariables []string
+
+// add records the variables undefined in the resource, every variable is reported once per resource
+func (u *undefinedVariables) add(resource string, names []string) {
+	for _, name := range names {
+		entry := fmt.Sprintf("%s in %s", name, resource)
+		duplicate := false
+		for _, existing := range *u {
+			if existing == entry {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			*u = append(*u, entry)
+		}
+	}
+}
+
+// err returns the error listing all the undefined variables, nil if there are none
+func (u undefinedVariables) err() error {
+	if len(u) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", UndefinedVariableErr, strings.Join(u, ", "))
+}
+
+// bindEnv sets the struct fields with `env` tag to the values of the environment variables, if they are set
+// The values are converted to the field types the same way as with WithTypeCoercion
+func bindEnv(v reflect.Value, path string) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, inline, skip := yamlFieldKey(field)
+		if skip {
+			continue
+		}
+		fieldPath := path
+		if !inline {
+			fieldPath = joinKeyPath(path, key)
+		}
+		if name := field.Tag.Get("env"); name != "" {
+			if value, ok := os.LookupEnv(name); ok {
+				if err := setEnvValue(v.Field(i), value); err != nil {
+					return fmt.Errorf("%s: env %s: %w: %q is not %s", fieldPath, name, CoercionErr, value, field.Type.Kind())
+				}
+				continue
+			}
+		}
+		if err := bindEnv(v.Field(i), fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setEnvValue sets the field to the environment variable value, strings are set as is,
+// the types which are not coerced, like durations, are decoded from the value as YAML
+func setEnvValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.String {
+		field.SetString(value)
+		return nil
+	}
+	text := value
+	if field.Type() != durationType {
+		tag, coerced, err := coerceScalar(strings.TrimSpace(value), field.Type())
+		if err != nil {
+			return err
+		}
+		if tag != "" {
+			text = coerced
+		}
+	}
+
+	return yaml.Unmarshal([]byte(text), field.Addr().Interface())
+}
@@ -0,0 +1,179 @@
+package yaml
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStrictSubstitution(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: db.yml}\nname: ${YAML_TEST_NAME}\nregion: ${YAML_TEST_REGION:-eu}"),
+		"db.yml":     []byte("db:\n  host: ${YAML_TEST_DB_HOST}\n  user: ${YAML_TEST_DB_USER}\n  password: ${YAML_TEST_DB_USER}"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name   string
+		Region string
+		DB     struct{ Host, User, Password string }
+	}
+
+	os.Setenv("YAML_TEST_NAME", "app")
+	os.Unsetenv("YAML_TEST_REGION")
+	os.Unsetenv("YAML_TEST_DB_HOST")
+	os.Unsetenv("YAML_TEST_DB_USER")
+	defer os.Unsetenv("YAML_TEST_NAME")
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithEnvSubstitution())
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "eu", ts.Region)
+	assert.Equal(t, "", ts.DB.Host)
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithStrictSubstitution())
+	assert.True(t, errors.Is(err, UndefinedVariableErr))
+	assert.EqualError(t, err, "undefined environment variable: YAML_TEST_DB_HOST in db.yml, YAML_TEST_DB_USER in db.yml")
+
+	os.Setenv("YAML_TEST_DB_HOST", "localhost")
+	os.Setenv("YAML_TEST_DB_USER", "admin")
+	defer os.Unsetenv("YAML_TEST_DB_HOST")
+	defer os.Unsetenv("YAML_TEST_DB_USER")
+	ts = testStruct{}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithStrictSubstitution())
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", ts.DB.Host)
+	assert.Equal(t, "admin", ts.DB.Password)
+}
+
+func TestEnvInterpolation(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: ${CONFIG_DIR}/db.yml}\n - {resource: $PROFILE.yml, ignore_errors: true}\n" +
+			"name: $APP_NAME\nregion: ${REGION:-eu}\nprice: 5$\nowner: ${OWNER}"),
+		"configs/db.yml": []byte("db: {host: $DB_HOST, port: ${DB_PORT:-5432}}"),
+		"prod.yml":       []byte("name: prod-$APP_NAME"),
+	}
+	reader := mapReader(files)
+	env := map[string]string{"CONFIG_DIR": "configs", "APP_NAME": "app", "DB_HOST": "localhost", "PROFILE": "prod"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	type testStruct struct {
+		Name, Region, Price, Owner string
+		DB                         struct {
+			Host string
+			Port int
+		}
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithEnvLookup(lookup))
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", ts.DB.Host, "resource paths are interpolated")
+	assert.Equal(t, 5432, ts.DB.Port)
+	assert.Equal(t, "app", ts.Name, "the base file overrides the imports")
+	assert.Equal(t, "eu", ts.Region)
+	assert.Equal(t, "5$", ts.Price)
+	assert.Equal(t, "", ts.Owner)
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithEnvLookup(lookup), WithStrictSubstitution())
+	assert.True(t, errors.Is(err, UndefinedVariableErr))
+	assert.EqualError(t, err, "undefined environment variable: OWNER in config.yml")
+
+	env["OWNER"], env["CONFIG_DIR"] = "ops", "missing"
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithStrictSubstitution(), WithEnvLookup(lookup))
+	assert.True(t, errors.Is(err, ImportNotFoundErr))
+	assert.EqualError(t, err, "config.yml -> missing/db.yml: no such file")
+}
+
+func TestEnvEscape(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		return map[string]string{"WORD": "secret"}[name], name == "WORD"
+	}
+
+	result, undefined := substituteEnv([]byte("password: pa$$word\nliteral: $${WORD}\nvalue: $$$WORD\nprice: 5$"), lookup)
+	assert.Equal(t, "password: pa$word\nliteral: ${WORD}\nvalue: $secret\nprice: 5$", string(result))
+	assert.Empty(t, undefined, "escaped references are not variables")
+
+	result, undefined = substituteEnv([]byte("password: pa$WORD"), lookup)
+	assert.Equal(t, "password: pasecret", string(result))
+	assert.Empty(t, undefined)
+}
+
+func TestEnvComments(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		return map[string]string{"WORD": "secret"}[name], name == "WORD"
+	}
+
+	src := "# see ${UNDOCUMENTED}\n" +
+		"password: $WORD # or $OTHER\n" +
+		"quoted: \"# $WORD\" # $OTHER\n" +
+		"single: 'it''s # $WORD'\n" +
+		"multi: \"first\n  # $WORD\"\n" +
+		"url: http://example.com/#$WORD\n" +
+		"script: |\n  # $WORD\n  echo\n# $OTHER\n"
+	result, undefined := substituteEnv([]byte(src), lookup)
+	assert.Equal(t, "# see ${UNDOCUMENTED}\n"+
+		"password: secret # or $OTHER\n"+
+		"quoted: \"# secret\" # $OTHER\n"+
+		"single: 'it''s # secret'\n"+
+		"multi: \"first\n  # secret\"\n"+
+		"url: http://example.com/#secret\n"+
+		"script: |\n  # secret\n  echo\n# $OTHER\n", string(result))
+	assert.Empty(t, undefined, "the variables of the comments are not looked up")
+
+	files := map[string][]byte{"config.yml": []byte("# see ${YAML_TEST_UNDOCUMENTED}\nname: app # ${YAML_TEST_UNDOCUMENTED}")}
+	var ts struct{ Name string }
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(mapReader(files)), WithStrictSubstitution())
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+}
+
+func TestWithEnvBinding(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: db.yml}\nname: app\ntimeout: 5s"),
+		"db.yml":     []byte("db:\n  host: localhost\n  port: 5432\n  tls: false"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name    string        `yaml:"name" env:"YAML_TEST_NAME"`
+		Timeout time.Duration `yaml:"timeout" env:"YAML_TEST_TIMEOUT"`
+		DB      struct {
+			Host string `yaml:"host" env:"YAML_TEST_DB_HOST"`
+			Port int    `yaml:"port" env:"YAML_TEST_DB_PORT"`
+			TLS  bool   `yaml:"tls" env:"YAML_TEST_DB_TLS"`
+		}
+	}
+
+	os.Setenv("YAML_TEST_DB_HOST", "db.prod:5433")
+	os.Setenv("YAML_TEST_DB_PORT", "6432")
+	os.Setenv("YAML_TEST_DB_TLS", "yes")
+	os.Setenv("YAML_TEST_TIMEOUT", "1m")
+	os.Unsetenv("YAML_TEST_NAME")
+	defer os.Unsetenv("YAML_TEST_DB_HOST")
+	defer os.Unsetenv("YAML_TEST_DB_PORT")
+	defer os.Unsetenv("YAML_TEST_DB_TLS")
+	defer os.Unsetenv("YAML_TEST_TIMEOUT")
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", ts.DB.Host, "the variables are bound only with the option")
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithEnvBinding())
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, time.Minute, ts.Timeout)
+	assert.Equal(t, "db.prod:5433", ts.DB.Host)
+	assert.Equal(t, 6432, ts.DB.Port)
+	assert.True(t, ts.DB.TLS)
+
+	os.Setenv("YAML_TEST_DB_PORT", "default")
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithEnvBinding())
+	assert.True(t, errors.Is(err, CoercionErr))
+	assert.EqualError(t, err, `db.port: env YAML_TEST_DB_PORT: can't coerce value to field type: "default" is not int`)
+}
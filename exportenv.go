@@ -0,0 +1,91 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var EnvNameCollisionErr = errors.New("keys are exported to the same environment variable")
+
+// ExportEnv processes config file and all it's imports tree, and flattens the merged config to environment variables:
+// the keys of nested mappings are joined with underscores, list items get their indexes as keys, so db.hosts[0]
+// of prefix app becomes APP_DB_HOSTS_0. The names are upper-cased, the characters which are not letters or digits
+// are replaced with underscores. The keys which get the same name, like db.host and db_host, are reported
+// with EnvNameCollisionErr. ioutil.ReadFile is used if reader is nil
+func ExportEnv(configPath string, prefix string, reader ReadFileFunc) (map[string]string, error) {
+	var opts []Option
+	if reader != nil {
+		opts = append(opts, WithReader(reader))
+	}
+	merged, err := mergedTree(configPath, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	env := make(map[string]string)
+	if err := flattenEnv(env, make(map[string]string), envName(prefix), "", merged); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// flattenEnv adds the variables of the value with the name, or with the name prefix for mappings and lists.
+// paths maps the added names to the key paths they are exported from, the mapping keys are visited in sorted order,
+// so the same pair of colliding keys is reported on every run
+func flattenEnv(env, paths map[string]string, name, path string, value interface{}) error {
+	join := func(key string) string {
+		if name == "" {
+			return envName(key)
+		}
+		return name + "_" + envName(key)
+	}
+
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		keys := make([]interface{}, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			if err := flattenEnv(env, paths, join(fmt.Sprint(key)), joinKeyPath(path, key), v[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if err := flattenEnv(env, paths, join(strconv.Itoa(i)), joinKeyPath(path, i), item); err != nil {
+				return err
+			}
+		}
+	default:
+		if existing, ok := paths[name]; ok {
+			return fmt.Errorf("%s and %s: %w: %s", existing, path, EnvNameCollisionErr, name)
+		}
+		paths[name] = path
+		if v == nil {
+			env[name] = ""
+		} else {
+			env[name] = fmt.Sprint(v)
+		}
+	}
+
+	return nil
+}
+
+// envName converts the key to the environment variable name
+func envName(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, key)
+}
@@ -0,0 +1,49 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportEnv(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\na: config1, final value\nhosts: [primary, replica]"),
+		"config2.yml": []byte("imports:\n - {resource: config3.yml}\nb:\n  c: C value from config 2\n  max-conns: 10"),
+		"config3.yml": []byte("b:\n  c: will be overwritten\n  d:\n    e: will not be overwritten\n    f: true\n  g: ~"),
+	}
+	reader := mapReader(files)
+
+	env, err := ExportEnv("config1.yml", "app", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"APP_A":           "config1, final value",
+		"APP_HOSTS_0":     "primary",
+		"APP_HOSTS_1":     "replica",
+		"APP_B_C":         "C value from config 2",
+		"APP_B_MAX_CONNS": "10",
+		"APP_B_D_E":       "will not be overwritten",
+		"APP_B_D_F":       "true",
+		"APP_B_G":         "",
+	}, env)
+
+	env, err = ExportEnv("config3.yml", "", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "will not be overwritten", env["B_D_E"])
+
+	_, err = ExportEnv("missing.yml", "app", reader)
+	assert.EqualError(t, err, "no such file")
+}
+
+func TestExportEnvNameCollision(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("db:\n  host: primary\ndb_host: replica\nmax-conns: 10\nmax_conns: 20"),
+	}
+
+	for i := 0; i < 10; i++ {
+		_, err := ExportEnv("config.yml", "app", mapReader(files))
+		assert.True(t, errors.Is(err, EnvNameCollisionErr))
+		assert.EqualError(t, err, "db.host and db_host: keys are exported to the same environment variable: APP_DB_HOST")
+	}
+}
@@ -0,0 +1,28 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var NoConfigFileErr = errors.New("none of the config files can be read")
+
+// ProcessFirstExisting processes the first of the config files which can be read, with all it's imports tree, into dst
+// The paths are tried in order, the other files are ignored even if the chosen one fails to process
+func ProcessFirstExisting(paths []string, dst interface{}, opts ...Option) error {
+	if err := checkDst(dst); err != nil {
+		return err
+	}
+
+	o := newOptions(opts)
+	for _, path := range paths {
+		if _, err := o.read(configImport{Resource: path}); err != nil {
+			continue
+		}
+		_, err := processFile(path, dst, o)
+		return err
+	}
+
+	return fmt.Errorf("%s: %w", strings.Join(paths, ", "), NoConfigFileErr)
+}
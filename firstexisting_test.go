@@ -0,0 +1,36 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessFirstExisting(t *testing.T) {
+	files := map[string][]byte{
+		"home/.config/app.yml": []byte("imports:\n - {resource: db.yml}\nname: home"),
+		"home/.config/db.yml":  []byte("db: {host: localhost}"),
+		"etc/app.yml":          []byte("name: etc"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		Name string
+		DB   struct{ Host string }
+	}
+
+	err := ProcessFirstExisting([]string{"app.yml", "home/.config/app.yml", "etc/app.yml"}, &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "home", ts.Name)
+	assert.Equal(t, "localhost", ts.DB.Host)
+
+	files["home/.config/app.yml"] = []byte("imports:\n - {resource: missing.yml}\nname: home")
+	err = ProcessFirstExisting([]string{"app.yml", "home/.config/app.yml", "etc/app.yml"}, &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ImportNotFoundErr), "the next file isn't used if the first readable one fails")
+
+	err = ProcessFirstExisting([]string{"app.yml", "usr/app.yml"}, &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, NoConfigFileErr))
+	assert.EqualError(t, err, "app.yml, usr/app.yml: none of the config files can be read")
+
+	assert.Equal(t, WrongDstTypeErr, ProcessFirstExisting([]string{"etc/app.yml"}, ts, WithReader(reader)))
+}
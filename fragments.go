@@ -0,0 +1,68 @@
+package yaml
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// ProcessFragmentDir processes all the .yml and .yaml files of the directory with their imports trees in the order
+// of names, every fragment is applied on top of the previous ones, as if it imported them. Hidden files are skipped
+// The fragments are loaded into a copy of dst, which is set to dst only if all of them are loaded and validated,
+// so dst isn't changed by a failed fragment. The checks of the merged config, like WithNonEmptyValidation or
+// WithMaxOverridesPerKey, run once all the fragments are merged
+func ProcessFragmentDir(dir string, dst interface{}, opts ...Option) error {
+	if err := checkDst(dst); err != nil {
+		return err
+	}
+
+	// ReadDir returns the entries sorted by name
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var (
+		o = newOptions(opts)
+		// result collects the results of all the fragments, loading resets it for every file
+		result Result
+		// state merges all the fragments, the post-merge checks run once they are loaded
+		state = newMergeState()
+	)
+	scratch := DeepCopy(dst)
+	// the fragments are layered like the imports of a single tree, so the defaults are the lowest layer of all
+	if err := o.applyDefaults(scratch); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if !entry.Mode().IsRegular() || strings.HasPrefix(name, ".") || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		err := state.load(filepath.Join(dir, name), scratch, o)
+		if o.result != nil {
+			result.Skipped = append(result.Skipped, o.result.Skipped...)
+			result.Warnings = append(result.Warnings, o.result.Warnings...)
+			for _, resource := range o.result.Loaded {
+				result.addLoaded(resource)
+			}
+			*o.result = result
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := state.finish(scratch, o); err != nil {
+		return err
+	}
+	if o.validate != nil {
+		if err := o.validate(scratch); err != nil {
+			return err
+		}
+	}
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(scratch).Elem())
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package yaml
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessFragmentDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"001-base.yml":     "name: base\nregion: eu\ndb:\n  host: localhost\n  port: 5432",
+		"002-override.yml": "imports:\n  - {resource: common/db.yml}\nregion: us",
+		"010-local.yaml":   "db:\n  port: 6432",
+		"common/db.yml":    "db:\n  host: db.internal\n  port: 1",
+		"README.md":        "not a config",
+		".hidden.yml":      "name: hidden",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ts struct {
+		Name   string
+		Region string
+		DB     struct {
+			Host string
+			Port int
+		}
+	}
+	err = ProcessFragmentDir(dir, &ts)
+	assert.Nil(t, err)
+	assert.Equal(t, "base", ts.Name)
+	assert.Equal(t, "us", ts.Region)
+	assert.Equal(t, "db.internal", ts.DB.Host, "the import of a fragment overrides the previous fragments")
+	assert.Equal(t, 6432, ts.DB.Port)
+
+	err = ProcessFragmentDir(filepath.Join(dir, "missing"), &ts)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProcessFragmentDirDefaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte("a: from a"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "b.yml"), []byte("b: from b"), 0644))
+
+	type testStruct struct {
+		A, B, C string
+	}
+	ts := testStruct{A: "loaded before", C: "loaded before"}
+	err = ProcessFragmentDir(dir, &ts, WithDefaults(func() interface{} { return testStruct{C: "default"} }))
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{A: "from a", B: "from b", C: "default"}, ts, "the defaults are applied once, before all the fragments")
+}
+
+func TestProcessFragmentDirValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte("a: from a"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "b.yml"), []byte("b: from b"), 0644))
+
+	type testStruct struct {
+		A, B string
+	}
+	var (
+		ts        testStruct
+		validated []testStruct
+	)
+	err = ProcessFragmentDir(dir, &ts, WithValidate(func(dst interface{}) error {
+		validated = append(validated, *dst.(*testStruct))
+		return nil
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []testStruct{{A: "from a", B: "from b"}}, validated, "dst is validated once all the fragments are loaded")
+}
+
+func TestProcessFragmentDirFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte("a: from a"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "b.yml"), []byte("b: [unclosed"), 0644))
+
+	type testStruct struct {
+		A, B string
+	}
+	ts := testStruct{A: "loaded before"}
+	err = ProcessFragmentDir(dir, &ts)
+	assert.NotNil(t, err)
+	assert.Equal(t, testStruct{A: "loaded before"}, ts, "dst isn't changed by the fragments loaded before the failed one")
+
+	config := map[string]interface{}{"a": "loaded before"}
+	err = ProcessFragmentDir(dir, &config)
+	assert.NotNil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "loaded before"}, config)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "b.yml"), []byte("b: from b"), 0644))
+	err = ProcessFragmentDir(dir, &ts, WithValidate(func(dst interface{}) error {
+		return errors.New("invalid")
+	}))
+	assert.EqualError(t, err, "invalid")
+	assert.Equal(t, testStruct{A: "loaded before"}, ts, "dst isn't changed by the failed validation")
+}
+
+func TestProcessFragmentDirChecks(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte("host: ''\nport: 1"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "b.yml"), []byte("host: localhost\nport: 2"), 0644))
+
+	type testStruct struct {
+		Host string `config:"nonempty"`
+		Port int
+	}
+	var ts testStruct
+	err := ProcessFragmentDir(dir, &ts, WithNonEmptyValidation())
+	assert.Nil(t, err, "the value of the merged fragments is checked")
+	assert.Equal(t, testStruct{Host: "localhost", Port: 2}, ts)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "c.yml"), []byte("port: 3"), 0644))
+	err = ProcessFragmentDir(dir, &ts, WithMaxOverridesPerKey(2))
+	assert.NotNil(t, err, "the overrides are counted across the fragments")
+	err = ProcessFragmentDir(dir, &ts, WithMaxOverridesPerKey(3))
+	assert.Nil(t, err)
+}
@@ -0,0 +1,32 @@
+package yaml
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ProcessFileWithImportsFS processes config file and all it's imports tree read from fsys, like embed.FS
+// Imports are resolved with forward-slash paths, as fs.FS has no rooted paths, the resources with leading slash
+// are resolved from the root of fsys
+func ProcessFileWithImportsFS(fsys fs.FS, configPath string, dst interface{}, opts ...Option) error {
+	opts = append(opts[:len(opts):len(opts)], WithReader(fsReader(fsys)), WithGlob(fsGlob(fsys)), func(o *options) {
+		o.slashPaths = true
+	})
+
+	return ProcessFileWithImports(configPath, dst, opts...)
+}
+
+// fsReader reads the files of fsys, the names are cleaned to be valid fs.FS paths
+func fsReader(fsys fs.FS) ReadFileFunc {
+	return func(filename string) ([]byte, error) {
+		return fs.ReadFile(fsys, strings.TrimPrefix(path.Clean(filename), "/"))
+	}
+}
+
+// fsGlob expands the patterns with the files of fsys, the patterns are cleaned like the names read by fsReader
+func fsGlob(fsys fs.FS) GlobFunc {
+	return func(pattern string) ([]string, error) {
+		return fs.Glob(fsys, strings.TrimPrefix(path.Clean(pattern), "/"))
+	}
+}
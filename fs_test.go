@@ -0,0 +1,49 @@
+package yaml
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessFileWithImportsFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"configs/app.yml": {Data: []byte("imports:\n" +
+			" - {resource: db/main.yml}\n" +
+			" - {resource: /shared/log.yml}\n" +
+			" - {resource: ./cache.yml}\n" +
+			"name: app")},
+		"configs/db/main.yml":    {Data: []byte("imports:\n - {resource: db/replica.yml}\ndb: {host: localhost}")},
+		"configs/db/replica.yml": {Data: []byte("db: {host: replica, port: 5432}")},
+		"configs/cache.yml":      {Data: []byte("cache: {size: 64}")},
+		"shared/log.yml":         {Data: []byte("log: info")},
+		"configs/outside.yml":    {Data: []byte("imports:\n - {resource: ../../etc/x.yml}")},
+	}
+	type testStruct struct {
+		Name string
+		Log  string
+		DB   struct {
+			Host string
+			Port int
+		}
+		Cache struct{ Size int }
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImportsFS(fsys, "configs/app.yml", &ts)
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "localhost", ts.DB.Host)
+	assert.Equal(t, 5432, ts.DB.Port, "nested imports are resolved against the base config directory")
+	assert.Equal(t, 64, ts.Cache.Size)
+	assert.Equal(t, "info", ts.Log, "leading slash resource is read from the root of fs")
+
+	err = ProcessFileWithImportsFS(fsys, "configs/outside.yml", &ts)
+	assert.True(t, errors.Is(err, ImportNotFoundErr), "resources outside of fs can't be read")
+
+	err = ProcessFileWithImportsFS(fsys, "configs/missing.yml", &ts)
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
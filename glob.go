@@ -0,0 +1,72 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	GlobNoMatchErr     = errors.New("no config files match the resource pattern")
+	GlobUnsupportedErr = errors.New("resource patterns need a glob function for custom readers")
+)
+
+// GlobFunc returns the names of the files matching the pattern, like filepath.Glob does
+type GlobFunc func(pattern string) ([]string, error)
+
+// WithGlob sets the function used to expand the resources with patterns like conf.d/*.yml
+// filepath.Glob is used with the default reader, custom readers without glob function don't support patterns
+func WithGlob(glob GlobFunc) Option {
+	return func(o *options) {
+		o.glob = glob
+	}
+}
+
+// isGlob checks if the resource is a pattern, URLs are never patterns
+func isGlob(resource string) bool {
+	return !isURL(resource) && strings.ContainsAny(resource, "*?[")
+}
+
+// expandGlob replaces the import of the resource pattern with the imports of the matching files in sorted order,
+// so the later ones in order override the earlier ones. The file declaring the import and the base config
+// are never matched. A pattern matching nothing fails discovery unless the import ignores errors,
+// then it's listed in Result.Skipped
+func (o *options) expandGlob(ci configImport, importer, configPath string) ([]configImport, error) {
+	if !isGlob(ci.Resource) {
+		return []configImport{ci}, nil
+	}
+	glob := o.glob
+	if glob == nil {
+		if o.customReader {
+			return nil, fmt.Errorf("%s: %s: %w", importer, ci.Resource, GlobUnsupportedErr)
+		}
+		glob = filepath.Glob
+	}
+
+	matches, err := glob(ci.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", importer, ci.Resource, err)
+	}
+	sort.Strings(matches)
+	var imports []configImport
+	for _, match := range matches {
+		if filepath.Clean(match) == filepath.Clean(importer) || filepath.Clean(match) == filepath.Clean(configPath) {
+			continue
+		}
+		imported := ci
+		imported.Resource = match
+		imports = append(imports, imported)
+	}
+	if len(imports) == 0 {
+		if !ci.IgnoreErrors {
+			return nil, fmt.Errorf("%s: %s: %w", importer, ci.Resource, GlobNoMatchErr)
+		}
+		if o.result != nil {
+			o.result.Skipped = append(o.result.Skipped, SkippedImport{Resource: ci.Resource, Reason: "no files match the pattern"})
+		}
+	}
+
+	return imports, nil
+}
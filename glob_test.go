@@ -0,0 +1,90 @@
+package yaml
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobImports(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0755))
+	files := map[string]string{
+		"app.yml":          "imports:\n - {resource: conf.d/*.yml}\nname: app",
+		"conf.d/b.yml":     "b: b\nlevel: b",
+		"conf.d/a.yml":     "a: a\nlevel: a",
+		"conf.d/c.yml":     "c: c\nlevel: c",
+		"conf.d/notes.txt": "level: txt",
+		"empty.yml":        "imports:\n - {resource: missing.d/*.yml}\nname: empty",
+		"ignored.yml":      "imports:\n - {resource: missing.d/*.yml, ignore_errors: true}\nname: ignored",
+	}
+	for name, content := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	type testStruct struct {
+		Name, A, B, C, Level string
+	}
+
+	var ts testStruct
+	result := Result{}
+	err := ProcessFileWithImports(filepath.Join(dir, "app.yml"), &ts, WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{Name: "app", A: "a", B: "b", C: "c", Level: "c"}, ts, "the last file in sorted order wins")
+	assert.Equal(t, []string{
+		filepath.Join(dir, "conf.d/a.yml"),
+		filepath.Join(dir, "conf.d/b.yml"),
+		filepath.Join(dir, "conf.d/c.yml"),
+		filepath.Join(dir, "app.yml"),
+	}, result.Loaded)
+
+	err = ProcessFileWithImports(filepath.Join(dir, "empty.yml"), &ts)
+	assert.True(t, errors.Is(err, GlobNoMatchErr))
+	assert.Contains(t, err.Error(), filepath.Join(dir, "missing.d/*.yml"))
+
+	ts, result = testStruct{}, Result{}
+	err = ProcessFileWithImports(filepath.Join(dir, "ignored.yml"), &ts, WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, "ignored", ts.Name)
+	assert.Equal(t, []SkippedImport{{Resource: filepath.Join(dir, "missing.d/*.yml"), Reason: "no files match the pattern"}}, result.Skipped)
+}
+
+func TestGlobImportsOrder(t *testing.T) {
+	files := map[string][]byte{
+		"config/app.yml":   []byte("imports:\n - {resource: '*.yml'}\n - {resource: local.yml}\nlevel: app"),
+		"config/a.yml":     []byte("level: a\nfrom: a"),
+		"config/b.yml":     []byte("level: b"),
+		"config/local.yml": []byte("from: local"),
+	}
+	reader := mapReader(files)
+	// the matches are sorted whatever order the glob function returns them in
+	glob := func(pattern string) ([]string, error) {
+		return []string{"config/b.yml", "config/app.yml", "config/a.yml"}, nil
+	}
+	var ts struct{ Level, From string }
+
+	for i := 0; i < 3; i++ {
+		result := Result{}
+		err := ProcessFileWithImports("config/app.yml", &ts, WithReader(reader), WithGlob(glob), WithResult(&result))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"config/a.yml", "config/b.yml", "config/local.yml", "config/app.yml"}, result.Loaded,
+			"the importing file isn't matched")
+		assert.Equal(t, "local", ts.From)
+	}
+
+	err := ProcessFileWithImports("config/app.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, GlobUnsupportedErr))
+
+	fsys := fstest.MapFS{}
+	for name, data := range files {
+		fsys[name] = &fstest.MapFile{Data: data}
+	}
+	result := Result{}
+	err = ProcessFileWithImportsFS(fsys, "config/app.yml", &ts, WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"config/a.yml", "config/b.yml", "config/local.yml", "config/app.yml"}, result.Loaded)
+}
@@ -1,5 +1,11 @@
-module "github.com/lispad/yaml"
+module github.com/lispad/yaml
+
+go 1.20
 
 require (
-	"gopkg.in/yaml.v2" v2.2.2
+	github.com/stretchr/testify v1.12.1
+	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require go.yaml.in/yaml/v3 v3.0.5 // indirect
@@ -0,0 +1,26 @@
+package yaml
+
+import "os"
+
+// notExistError is the error of mapReader for the files which are not in the map,
+// it matches os.ErrNotExist like the errors of ioutil.ReadFile do
+type notExistError string
+
+func (e notExistError) Error() string {
+	return string(e)
+}
+
+func (e notExistError) Is(target error) bool {
+	return target == os.ErrNotExist
+}
+
+// mapReader returns the reader of the files of the map, the files changed in the map by the test are read
+// with the new content. yamltest.MapReader can't be used by the tests of the package, as yamltest imports it
+func mapReader(files map[string][]byte) ReadFileFunc {
+	return func(filename string) ([]byte, error) {
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, notExistError("no such file")
+	}
+}
@@ -0,0 +1,145 @@
+// Package httpreader implements yaml.ReadFileFunc for config files served over HTTP
+// and addressed as http:// or https:// resources.
+//
+// Responses with ETag or Last-Modified headers are cached, and the next reads of the resource are conditional
+// requests, so the config server answers 304 Not Modified instead of sending unchanged files again.
+// Relative imports of a remote config are resolved against the remote directory,
+// so https://host/app/config.yml importing base.yml reads https://host/app/base.yml.
+// ReadFileTyped returns the content type of the responses too, so the JSON resources without extension
+// are parsed right with yaml.WithTypedReader.
+package httpreader
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/lispad/yaml"
+)
+
+var (
+	NotHTTPResourceErr  = errors.New("resource is not an http:// or https:// URL")
+	UnexpectedStatusErr = errors.New("unexpected response status")
+)
+
+type (
+	// HTTPReader reads http:// and https:// resources, caching the responses which can be validated
+	// Resources with other schemes or plain paths are read with Fallback, if it's set
+	HTTPReader struct {
+		Fallback yaml.ReadFileFunc
+
+		client *http.Client
+		mu     sync.Mutex
+		cache  map[string]cachedResponse
+	}
+
+	cachedResponse struct {
+		etag         string
+		lastModified string
+		contentType  string
+		data         []byte
+	}
+)
+
+// New returns HTTPReader sending requests with the client, http.DefaultClient if it's nil
+func New(client *http.Client) *HTTPReader {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPReader{
+		client: client,
+		cache:  make(map[string]cachedResponse),
+	}
+}
+
+// ReadFile reads the resource, it has yaml.ReadFileFunc signature
+func (r *HTTPReader) ReadFile(resource string) ([]byte, error) {
+	data, _, err := r.ReadFileTyped(resource)
+
+	return data, err
+}
+
+// ReadFileTyped reads the resource and returns the content type of the response, it has yaml.TypedReadFileFunc
+// signature, so the JSON resources without extension are parsed right. Fallback reads have no content type
+func (r *HTTPReader) ReadFileTyped(resource string) ([]byte, string, error) {
+	u, err := url.Parse(resource)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		if r.Fallback != nil {
+			data, err := r.Fallback(resource)
+			return data, "", err
+		}
+		return nil, "", fmt.Errorf("%s: %w", resource, NotHTTPResourceErr)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, resource, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", resource, err)
+	}
+	r.mu.Lock()
+	cached, isCached := r.cache[resource]
+	r.mu.Unlock()
+	if isCached {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && isCached:
+		return cached.data, cached.contentType, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", &statusError{resource: resource, status: resp.Status, code: resp.StatusCode}
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", resource, err)
+	}
+	etag, lastModified, contentType := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), resp.Header.Get("Content-Type")
+	r.mu.Lock()
+	if etag != "" || lastModified != "" {
+		r.cache[resource] = cachedResponse{etag: etag, lastModified: lastModified, contentType: contentType, data: data}
+	} else {
+		delete(r.cache, resource)
+	}
+	r.mu.Unlock()
+
+	return data, contentType, nil
+}
+
+// statusError is UnexpectedStatusErr of the resource, the ones of 404 Not Found and 410 Gone match os.ErrNotExist too,
+// so the loader reports the missing remote imports as not found, and the failures of the server as read errors
+type statusError struct {
+	resource string
+	status   string
+	code     int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s: %v: %s", e.resource, UnexpectedStatusErr, e.status)
+}
+
+func (e *statusError) Is(target error) bool {
+	switch target {
+	case UnexpectedStatusErr:
+		return true
+	case os.ErrNotExist:
+		return e.code == http.StatusNotFound || e.code == http.StatusGone
+	}
+
+	return false
+}
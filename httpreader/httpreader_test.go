@@ -0,0 +1,136 @@
+package httpreader
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/lispad/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPReader(t *testing.T) {
+	files := map[string]string{
+		"/app/config.yml": "imports:\n - {resource: base.yml}\nname: app",
+		"/app/base.yml":   "name: base\nregion: eu",
+	}
+	var (
+		mu sync.Mutex
+		// served counts the responses with body for every path
+		served = make(map[string]int)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		content, ok := files[req.URL.Path]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		etag := `"` + req.URL.Path + `-v1"`
+		w.Header().Set("ETag", etag)
+		if req.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		mu.Lock()
+		served[req.URL.Path]++
+		mu.Unlock()
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	reader := New(server.Client())
+	type testStruct struct{ Name, Region string }
+	for i := 0; i < 2; i++ {
+		var ts testStruct
+		err := yaml.ProcessFileWithImports(server.URL+"/app/config.yml", &ts, yaml.WithReader(reader.ReadFile))
+		assert.Nil(t, err)
+		assert.Equal(t, testStruct{Name: "app", Region: "eu"}, ts)
+	}
+	// every file is read twice per load, all the reads after the first one are answered with 304
+	assert.Equal(t, map[string]int{"/app/config.yml": 1, "/app/base.yml": 1}, served)
+
+	_, err := reader.ReadFile(server.URL + "/app/missing.yml")
+	assert.True(t, errors.Is(err, UnexpectedStatusErr))
+	assert.True(t, errors.Is(err, os.ErrNotExist), "404 is the missing file")
+
+	_, err = reader.ReadFile("config.yml")
+	assert.True(t, errors.Is(err, NotHTTPResourceErr))
+
+	reader.Fallback = func(filename string) ([]byte, error) {
+		return []byte("name: local"), nil
+	}
+	data, err := reader.ReadFile("config.yml")
+	assert.Nil(t, err)
+	assert.Equal(t, "name: local", string(data))
+}
+
+func TestHTTPReaderStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/app/config.yml":
+			w.Write([]byte("imports:\n - {resource: base.yml}"))
+		case "/app/moved.yml":
+			w.Write([]byte("imports:\n - {resource: gone.yml}"))
+		case "/app/gone.yml":
+			w.WriteHeader(http.StatusGone)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	reader := New(server.Client())
+
+	_, err := reader.ReadFile(server.URL + "/app/gone.yml")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	_, err = reader.ReadFile(server.URL + "/app/base.yml")
+	assert.True(t, errors.Is(err, UnexpectedStatusErr))
+	assert.False(t, errors.Is(err, os.ErrNotExist))
+	assert.EqualError(t, err, server.URL+"/app/base.yml: unexpected response status: 500 Internal Server Error")
+
+	err = yaml.ProcessFileWithImports(server.URL+"/app/config.yml", &struct{}{}, yaml.WithReader(reader.ReadFile))
+	assert.True(t, errors.Is(err, yaml.ImportReadErr), "the server error is not a missing file")
+	err = yaml.ProcessFileWithImports(server.URL+"/app/moved.yml", &struct{}{}, yaml.WithReader(reader.ReadFile))
+	assert.True(t, errors.Is(err, yaml.ImportNotFoundErr))
+}
+
+func TestHTTPReaderContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/app/config":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"config-v1"`)
+			if req.Header.Get("If-None-Match") == `"config-v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Write([]byte(`{"imports": [{"resource": "base"}], "name": "app", "limits": {"users": 10}}`))
+		case "/app/base":
+			w.Header().Set("Content-Type", "text/yaml")
+			w.Write([]byte("name: base\nregion: eu"))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+
+	reader := New(server.Client())
+	type testStruct struct {
+		Name, Region string
+		Limits       struct{ Users int }
+	}
+	for i := 0; i < 2; i++ {
+		var ts testStruct
+		err := yaml.ProcessFileWithImports(server.URL+"/app/config", &ts, yaml.WithTypedReader(reader.ReadFileTyped))
+		assert.Nil(t, err)
+		assert.Equal(t, "app", ts.Name)
+		assert.Equal(t, "eu", ts.Region)
+		assert.Equal(t, 10, ts.Limits.Users)
+	}
+
+	_, contentType, err := reader.ReadFileTyped(server.URL + "/app/config")
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json", contentType, "the content type is cached with the response")
+}
@@ -0,0 +1,56 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ignoredImportWarnings describes the imports which failed to load, but were skipped because they ignore errors:
+// the ones which can't be read, like the missing or timed out ones, are tolerated unless the keys declared
+// as provided by them are not set by other files, the present, but malformed ones are likely mistakes,
+// so they are always reported with the error
+func ignoredImportWarnings(importList []configImport, merged map[interface{}]interface{}) []string {
+	var warnings []string
+	for _, ci := range importList {
+		if !ci.corrupted || !ci.IgnoreErrors {
+			continue
+		}
+		var missing []string
+		for _, key := range ci.Provides {
+			if !hasKeyPath(merged, key) {
+				missing = append(missing, key)
+			}
+		}
+		var warning string
+		switch {
+		case ci.ignoredErr != nil && !ci.readFailed:
+			warning = fmt.Sprintf("ignored import is malformed and contributed nothing: %v", ci.ignoredErr)
+		case len(missing) > 0:
+			warning = ci.Resource + ": ignored import is missing"
+		default:
+			continue
+		}
+		if len(missing) > 0 {
+			warning += fmt.Sprintf(", expected keys are not set: %s", strings.Join(missing, ", "))
+		}
+		warnings = append(warnings, warning)
+	}
+
+	return warnings
+}
+
+// hasKeyPath checks if the dotted path of the key is set in the tree
+func hasKeyPath(tree map[interface{}]interface{}, path string) bool {
+	var value interface{} = tree
+	for _, key := range SplitKeyPath(path) {
+		m, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return false
+		}
+		if value, ok = m[key]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
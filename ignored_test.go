@@ -0,0 +1,107 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnoredImportWarnings(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n" +
+			" - {resource: config2.yml}\n" +
+			" - {resource: wrong_file.yaml, ignore_errors: true, provides: [b.c, b.d.e, a]}\n" +
+			"a: config1"),
+		"config2.yml": []byte("b:\n c: config2"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		A string
+		B struct{ C string }
+	}
+
+	result := Result{}
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, "config2", ts.B.C)
+	assert.Equal(t, []string{"wrong_file.yaml: ignored import is missing, expected keys are not set: b.d.e"}, result.Warnings)
+
+	files["config1.yml"] = []byte("imports:\n - {resource: wrong_file.yaml, ignore_errors: true}\na: config1")
+	result = Result{}
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Empty(t, result.Warnings, "the missing import is tolerated silently")
+	assert.Equal(t, []string{"config1.yml"}, result.Loaded)
+
+	// the import which is present, but malformed, is likely a mistake
+	files["wrong_file.yaml"] = []byte("b: [unclosed")
+	result = Result{}
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, "config1", ts.A)
+	assert.Equal(t, []string{"ignored import is malformed and contributed nothing: " +
+		"config1.yml -> wrong_file.yaml: yaml: line 1: did not find expected ',' or ']'"}, result.Warnings)
+	assert.Equal(t, []string{"config1.yml"}, result.Loaded)
+
+	files["wrong_file.yaml"] = []byte("b:\n d:\n  e: loaded")
+	result = Result{}
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Empty(t, result.Warnings)
+}
+
+func TestIgnoredImportTimeoutWarnings(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: slow.yml, ignore_errors: true}\na: config1"),
+		"slow.yml":    []byte("b: slow"),
+	}
+	reader := func(filename string) ([]byte, error) {
+		if filename == "slow.yml" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return files[filename], nil
+	}
+	var ts struct{ A, B string }
+
+	result := Result{}
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithReadTimeout(20*time.Millisecond), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, "config1", ts.A)
+	assert.Empty(t, result.Warnings, "the import which timed out is not malformed")
+
+	files["config1.yml"] = []byte("imports:\n - {resource: slow.yml, ignore_errors: true, provides: [b]}\na: config1")
+	result = Result{}
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithReadTimeout(20*time.Millisecond), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"slow.yml: ignored import is missing, expected keys are not set: b"}, result.Warnings)
+}
+
+func TestWithOnIgnoredError(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml":    []byte("imports:\n - {resource: wrong_file.yml, ignore_errors: true}\n - {resource: config2.yml}\na: config1"),
+		"config2.yml":    []byte("imports:\n - {resource: wrong_type.yml, ignore_errors: true}\nb: config2"),
+		"wrong_type.yml": []byte("b: [not, string]"),
+	}
+	reader := mapReader(files)
+	var ts struct{ A, B string }
+	ignored := map[string]error{}
+	onIgnoredError := func(resource string, err error) {
+		ignored[resource] = err
+	}
+
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithOnIgnoredError(onIgnoredError))
+	assert.Nil(t, err)
+	assert.Equal(t, "config2", ts.B)
+	assert.Len(t, ignored, 2)
+	assert.True(t, errors.Is(ignored["wrong_file.yml"], ImportNotFoundErr), "read failure is reported")
+	assert.EqualError(t, ignored["wrong_file.yml"], "config1.yml -> wrong_file.yml: no such file")
+	assert.True(t, errors.Is(ignored["wrong_type.yml"], ImportParseErr), "unmarshal failure is reported")
+
+	files["wrong_file.yml"] = []byte("b: [unclosed")
+	ignored = map[string]error{}
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithOnIgnoredError(onIgnoredError))
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(ignored["wrong_file.yml"], ImportParseErr), "malformed file is reported")
+}
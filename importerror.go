@@ -0,0 +1,72 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	ImportNotFoundErr = errors.New("imported config file is not found")
+	// ImportReadErr is the kind of the imports which exist, or aren't known not to, but fail to be read,
+	// like the ones without permissions or the remote ones failing with server errors
+	ImportReadErr  = errors.New("imported config file can't be read")
+	ImportParseErr = errors.New("imported config file is malformed")
+)
+
+// ImportError is the error of the import which failed to load, errors.Is matches both the kind of the failure,
+// ImportNotFoundErr, ImportReadErr or ImportParseErr, and the underlying cause
+type ImportError struct {
+	Resource string
+	// Importers is the chain of the files which led to the import, from the base config
+	Importers []string
+	Err       error
+	kind      error
+}
+
+// Error implements error, the cause is prefixed with the import chain and the resource unless it starts with it
+func (e *ImportError) Error() string {
+	message := e.Err.Error()
+	if !strings.HasPrefix(message, e.Resource) {
+		message = e.Resource + ": " + message
+	}
+	if len(e.Importers) == 0 {
+		return message
+	}
+
+	return strings.Join(e.Importers, " -> ") + " -> " + message
+}
+
+// Unwrap returns the underlying cause
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports if the target is the kind of the failure
+func (e *ImportError) Is(target error) bool {
+	return target == e.kind
+}
+
+// importError wraps the failure of the import with the kind. The errors of the entry config aren't import errors,
+// they are prefixed with the file name unless they already name it. Read timeouts and cancellations are returned as is
+func importError(kind error, i int, ci configImport, err error) error {
+	switch {
+	case errors.Is(err, ReadTimeoutErr), isCancelled(err), i == 0 && strings.Contains(err.Error(), ci.Resource):
+		return err
+	case i == 0:
+		return fmt.Errorf("%s: %w", ci.Resource, err)
+	}
+
+	return &ImportError{Resource: ci.Resource, Importers: ci.importers, Err: err, kind: kind}
+}
+
+// readErrorKind returns the kind of the read failure: ImportNotFoundErr if the error matches os.ErrNotExist,
+// as the errors of ioutil.ReadFile and fs.FS do, ImportReadErr otherwise
+func readErrorKind(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return ImportNotFoundErr
+	}
+
+	return ImportReadErr
+}
@@ -0,0 +1,136 @@
+package yaml
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestImportErrors(t *testing.T) {
+	var noFileErr = notExistError("no such file")
+	files := map[string][]byte{
+		"config/missing.yml": []byte("imports:\n - {resource: db.yml}\n - {resource: absent.yml}\nname: app"),
+		"config/broken.yml":  []byte("imports:\n - {resource: db.yml}\n - {resource: invalid.yml}\nname: app"),
+		"config/ignored.yml": []byte("imports:\n - {resource: absent.yml, ignore_errors: true}\n - {resource: invalid.yml, ignore_errors: true}\nname: app"),
+		"config/db.yml":      []byte("db: {host: localhost}"),
+		"config/invalid.yml": []byte("db: [unclosed"),
+		"config/root.yml":    []byte("name: [unclosed"),
+	}
+	reader := func(filename string) ([]byte, error) {
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, noFileErr
+	}
+	var ts struct {
+		Name string
+		DB   struct{ Host string }
+	}
+
+	err := ProcessFileWithImports("config/missing.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ImportNotFoundErr))
+	assert.False(t, errors.Is(err, ImportParseErr))
+	assert.True(t, errors.Is(err, noFileErr), "the cause is kept")
+	assert.EqualError(t, err, "config/missing.yml -> config/absent.yml: no such file")
+	var importErr *ImportError
+	assert.True(t, errors.As(err, &importErr))
+	assert.Equal(t, "config/absent.yml", importErr.Resource)
+	assert.Equal(t, []string{"config/missing.yml"}, importErr.Importers)
+
+	err = ProcessFileWithImports("config/broken.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ImportParseErr))
+	assert.False(t, errors.Is(err, ImportNotFoundErr))
+	assert.EqualError(t, err, "config/broken.yml -> config/invalid.yml: yaml: line 1: did not find expected ',' or ']'")
+
+	err = ProcessFileWithImports("config/ignored.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+
+	// the entry config isn't an import
+	err = ProcessFileWithImports("config/root.yml", &ts, WithReader(reader))
+	assert.False(t, errors.Is(err, ImportParseErr))
+	assert.EqualError(t, err, "config/root.yml: yaml: line 1: did not find expected ',' or ']'")
+	err = ProcessFileWithImports("config/absent.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, noFileErr))
+	assert.False(t, errors.As(err, &importErr))
+
+	err = ProcessFileWithImports("config/broken.yml", &ts, WithReader(reader), WithAggregateErrors())
+	var importErrors *ImportErrors
+	assert.True(t, errors.As(err, &importErrors))
+	assert.True(t, errors.As(err, &importErr), "aggregated errors are unwrapped")
+	assert.True(t, errors.Is(err, ImportParseErr))
+	assert.True(t, errors.Is(importErrors.Errors[0], ImportParseErr))
+
+	// the chain of the importers is reported, the cause is kept for errors.Is
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "app.yml"), []byte("imports:\n - {resource: db.yml}"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "db.yml"), []byte("imports:\n - {resource: absent.yml}"), 0644))
+	err = ProcessFileWithImports(filepath.Join(dir, "app.yml"), &ts)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.True(t, errors.Is(err, ImportNotFoundErr))
+	assert.True(t, strings.HasPrefix(err.Error(), filepath.Join(dir, "app.yml")+" -> "+filepath.Join(dir, "db.yml")+" -> "+filepath.Join(dir, "absent.yml")+": "))
+
+	// the files which exist, but can't be read, are not missing
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "db.yml"), []byte("imports:\n - {resource: conf.d}"), 0644))
+	err = ProcessFileWithImports(filepath.Join(dir, "app.yml"), &ts)
+	assert.True(t, errors.Is(err, ImportReadErr), "the directory can't be read")
+	assert.False(t, errors.Is(err, ImportNotFoundErr))
+
+	serverErr := errors.New("500 Internal Server Error")
+	err = ProcessFileWithImports("config/missing.yml", &ts, WithReader(func(filename string) ([]byte, error) {
+		if filename == "config/absent.yml" {
+			return nil, serverErr
+		}
+		return reader(filename)
+	}))
+	assert.True(t, errors.Is(err, ImportReadErr))
+	assert.False(t, errors.Is(err, ImportNotFoundErr), "only the errors matching os.ErrNotExist are not found")
+	assert.True(t, errors.Is(err, serverErr))
+}
+
+func TestImportErrorsLines(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml":          []byte("imports:\n - {resource: services/app.yml}\nname: app"),
+		"services/app.yml":    []byte("imports:\n - {resource: services/db.yml}\nport: 8080"),
+		"services/db.yml":     []byte("db:\n  host: localhost\n  port: [5432]"),
+		"syntax.yml":          []byte("imports:\n - {resource: services/syntax.yml}"),
+		"services/syntax.yml": []byte("db:\n  host: localhost\n port: 5432"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		Name string
+		Port int
+		DB   struct {
+			Host string
+			Port int
+		}
+	}
+
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ImportParseErr))
+	assert.EqualError(t, err, "config.yml -> services/app.yml -> services/db.yml: yaml: unmarshal errors:\n"+
+		"  line 3: cannot unmarshal !!seq into int")
+	var typeErr *yaml.TypeError
+	assert.True(t, errors.As(err, &typeErr), "the type error of the nested import is kept")
+	assert.Equal(t, []string{"line 3: cannot unmarshal !!seq into int"}, typeErr.Errors)
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithStrict())
+	assert.True(t, errors.As(err, &typeErr), "the type error is kept in strict mode")
+
+	files["services/db.yml"] = []byte("db:\n  host: localhost\nextra: true")
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithStrict())
+	assert.True(t, errors.As(err, &typeErr))
+	assert.Equal(t, []string{"line 3: field extra not found in type struct { Name string; Port int; DB struct { Host string; Port int } }"},
+		typeErr.Errors)
+	assert.True(t, strings.HasPrefix(err.Error(), "config.yml -> services/app.yml -> services/db.yml: "))
+
+	err = ProcessFileWithImports("syntax.yml", &ts, WithReader(reader))
+	assert.EqualError(t, err, "syntax.yml -> services/syntax.yml: yaml: line 2: did not find expected key")
+}
@@ -0,0 +1,79 @@
+package yaml
+
+type (
+	// ImportGraph is the imports tree of the config file, the files imported by several files are single nodes
+	// with several incoming edges
+	ImportGraph struct {
+		// Nodes are the discovered files, the entry config is the first one
+		Nodes []ImportGraphNode
+		// Edges link the importing files to the imported ones, the imports of every file are listed
+		// in the order of declaration
+		Edges []ImportEdge
+	}
+
+	// ImportGraphNode is a file of the imports tree
+	ImportGraphNode struct {
+		Resource string
+		// IgnoreErrors is set if all the imports of the file ignore errors
+		IgnoreErrors bool
+		// Corrupted is set for the files which couldn't be read or parsed, they have no imports
+		Corrupted bool
+		// Parent is the index of the node which the file was discovered from, -1 for the entry config
+		Parent int
+	}
+
+	// ImportEdge is the import of Nodes[To] declared by Nodes[From]
+	ImportEdge struct {
+		From, To int
+	}
+)
+
+// DiscoverImports returns the imports graph of the config file without loading the values
+func DiscoverImports(configPath string, opts ...Option) (*ImportGraph, error) {
+	o := newOptions(opts)
+	var (
+		parents []int
+		edges   []ImportEdge
+	)
+	o.importParents, o.importEdges = &parents, &edges
+	importList, err := getReverseOrderedImports(configPath, o)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &ImportGraph{Nodes: make([]ImportGraphNode, len(importList)), Edges: edges}
+	for i, ci := range importList {
+		graph.Nodes[i] = ImportGraphNode{
+			Resource:     ci.Resource,
+			IgnoreErrors: ci.IgnoreErrors,
+			Corrupted:    ci.corrupted,
+			Parent:       parents[i],
+		}
+	}
+
+	return graph, nil
+}
+
+// Imports returns the indexes of the nodes imported by the i-th node, in the order of declaration
+func (g *ImportGraph) Imports(i int) []int {
+	var imports []int
+	for _, edge := range g.Edges {
+		if edge.From == i {
+			imports = append(imports, edge.To)
+		}
+	}
+
+	return imports
+}
+
+// Importers returns the indexes of the nodes importing the i-th node
+func (g *ImportGraph) Importers(i int) []int {
+	var importers []int
+	for _, edge := range g.Edges {
+		if edge.To == i {
+			importers = append(importers, edge.From)
+		}
+	}
+
+	return importers
+}
@@ -0,0 +1,61 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoverImports(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml":          []byte("imports:\n - {resource: a.yml}\n - {resource: b.yml}\nname: app"),
+		"a.yml":               []byte("imports:\n - {resource: shared/shared.yml}"),
+		"b.yml":               []byte("imports:\n - {resource: shared/shared.yml}\n - {resource: wrong_file.yml, ignore_errors: true}"),
+		"shared/shared.yml":   []byte("imports:\n - {resource: shared/base.yml}"),
+		"shared/base.yml":     []byte("name: base"),
+		"single.yml":          []byte("name: single"),
+		"circular.yml":        []byte("imports:\n - {resource: circular_import.yml}"),
+		"circular_import.yml": []byte("imports:\n - {resource: circular_import.yml}"),
+	}
+	reader := mapReader(files)
+
+	graph, err := DiscoverImports("config.yml", WithReader(reader))
+	assert.Nil(t, err)
+	resources := make(map[string]int)
+	for i, n := range graph.Nodes {
+		resources[n.Resource] = i
+	}
+	assert.Len(t, graph.Nodes, 6, "the shared file is a single node")
+	assert.Equal(t, ImportGraphNode{Resource: "config.yml", Parent: -1}, graph.Nodes[0])
+	assert.Equal(t, ImportGraphNode{Resource: "wrong_file.yml", IgnoreErrors: true, Corrupted: true, Parent: resources["b.yml"]},
+		graph.Nodes[resources["wrong_file.yml"]])
+
+	var edges [][2]string
+	for _, edge := range graph.Edges {
+		edges = append(edges, [2]string{graph.Nodes[edge.From].Resource, graph.Nodes[edge.To].Resource})
+	}
+	assert.ElementsMatch(t, [][2]string{
+		{"config.yml", "a.yml"},
+		{"config.yml", "b.yml"},
+		{"a.yml", "shared/shared.yml"},
+		{"b.yml", "shared/shared.yml"},
+		{"b.yml", "wrong_file.yml"},
+		{"shared/shared.yml", "shared/base.yml"},
+	}, edges)
+
+	assert.Equal(t, []int{resources["a.yml"], resources["b.yml"]}, graph.Imports(0), "the imports are in the order of declaration")
+	assert.Equal(t, []int{resources["shared/shared.yml"], resources["wrong_file.yml"]}, graph.Imports(resources["b.yml"]))
+	assert.ElementsMatch(t, []int{resources["a.yml"], resources["b.yml"]}, graph.Importers(resources["shared/shared.yml"]))
+	assert.Empty(t, graph.Importers(0))
+	shared := graph.Nodes[resources["shared/shared.yml"]]
+	assert.Contains(t, []int{resources["a.yml"], resources["b.yml"]}, shared.Parent)
+	assert.Equal(t, resources["shared/shared.yml"], graph.Nodes[resources["shared/base.yml"]].Parent)
+
+	graph, err = DiscoverImports("single.yml", WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, &ImportGraph{Nodes: []ImportGraphNode{{Resource: "single.yml", Parent: -1}}}, graph)
+
+	_, err = DiscoverImports("circular.yml", WithReader(reader))
+	assert.True(t, errors.Is(err, CircularImportErr))
+}
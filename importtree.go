@@ -0,0 +1,50 @@
+package yaml
+
+import "encoding/json"
+
+// ImportNode is a file of the imports tree, as it's serialized by ImportTreeJSON:
+//
+//	{"resource": "config.yml", "ignore_errors": false, "corrupted": false, "children": [...]}
+//
+// Children are listed in the order of declaration, corrupted files have no children as they can't be read
+type ImportNode struct {
+	Resource     string       `json:"resource"`
+	IgnoreErrors bool         `json:"ignore_errors"`
+	Corrupted    bool         `json:"corrupted"`
+	Children     []ImportNode `json:"children"`
+}
+
+// ImportTreeJSON discovers the imports tree of the config file and returns it as JSON encoded ImportNode
+// ioutil.ReadFile is used if reader is nil
+func ImportTreeJSON(configPath string, reader ReadFileFunc) ([]byte, error) {
+	var opts []Option
+	if reader != nil {
+		opts = append(opts, WithReader(reader))
+	}
+	o := newOptions(opts)
+	var parents []int
+	o.importParents = &parents
+	importList, err := getReverseOrderedImports(configPath, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(importTree(importList, parents, 0))
+}
+
+// importTree builds the tree of the discovered import, the children were discovered in reverse order
+func importTree(importList []configImport, parents []int, i int) ImportNode {
+	n := ImportNode{
+		Resource:     importList[i].Resource,
+		IgnoreErrors: importList[i].IgnoreErrors,
+		Corrupted:    importList[i].corrupted,
+		Children:     []ImportNode{},
+	}
+	for j := len(importList) - 1; j > i; j-- {
+		if parents[j] == i {
+			n.Children = append(n.Children, importTree(importList, parents, j))
+		}
+	}
+
+	return n
+}
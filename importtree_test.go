@@ -0,0 +1,41 @@
+package yaml
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportTreeJSON(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml":        []byte("imports:\n - {resource: config2.yml}\n - {resource: config3.yml}\na: 1"),
+		"config2.yml":        []byte("imports:\n - {resource: subdir/config4.yml}\n - {resource: wrong_file.yml, ignore_errors: true}"),
+		"config3.yml":        []byte("imports:\n - {resource: subdir/config5.yml}"),
+		"subdir/config4.yml": []byte("b: 4"),
+		"subdir/config5.yml": []byte("c: 5"),
+	}
+	reader := mapReader(files)
+
+	data, err := ImportTreeJSON("config1.yml", reader)
+	assert.Nil(t, err)
+
+	var tree ImportNode
+	assert.Nil(t, json.Unmarshal(data, &tree))
+	assert.Equal(t, ImportNode{
+		Resource: "config1.yml",
+		Children: []ImportNode{
+			{Resource: "config2.yml", Children: []ImportNode{
+				{Resource: "subdir/config4.yml", Children: []ImportNode{}},
+				{Resource: "wrong_file.yml", IgnoreErrors: true, Corrupted: true, Children: []ImportNode{}},
+			}},
+			{Resource: "config3.yml", Children: []ImportNode{
+				{Resource: "subdir/config5.yml", Children: []ImportNode{}},
+			}},
+		},
+	}, tree)
+	assert.Contains(t, string(data), `{"resource":"subdir/config5.yml","ignore_errors":false,"corrupted":false,"children":[]}`)
+
+	_, err = ImportTreeJSON("missing.yml", reader)
+	assert.EqualError(t, err, "missing.yml: no such file")
+}
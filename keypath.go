@@ -0,0 +1,45 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyPathEscaper escapes the dots of the keys, so a key named my.key differs from the key of nested mapping
+var keyPathEscaper = strings.NewReplacer(`\`, `\\`, ".", `\.`)
+
+// joinKeyPath appends the key to the dotted path of its parent mapping
+// Dots and backslashes of the key are escaped with backslash: the key my.key of db mapping is db.my\.key
+func joinKeyPath(path string, key interface{}) string {
+	escaped := keyPathEscaper.Replace(fmt.Sprint(key))
+	if path == "" {
+		return escaped
+	}
+
+	return path + "." + escaped
+}
+
+// SplitKeyPath splits the dotted path of a key, as reported by SourceMap and errors, into the keys of the mappings
+func SplitKeyPath(path string) []string {
+	var (
+		keys    []string
+		key     strings.Builder
+		escaped bool
+	)
+	for _, r := range path {
+		switch {
+		case escaped:
+			key.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteRune(r)
+		}
+	}
+
+	return append(keys, key.String())
+}
@@ -0,0 +1,52 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyPath(t *testing.T) {
+	testCases := []struct {
+		keys []string
+		path string
+	}{
+		{[]string{"db", "host"}, "db.host"},
+		{[]string{"my.key"}, `my\.key`},
+		{[]string{"my", "key"}, "my.key"},
+		{[]string{`C:\`, "a.b", ""}, `C:\\.a\.b.`},
+	}
+
+	for _, tc := range testCases {
+		path := ""
+		for _, key := range tc.keys {
+			path = joinKeyPath(path, key)
+		}
+		assert.Equal(t, tc.path, path)
+		assert.Equal(t, tc.keys, SplitKeyPath(path))
+	}
+}
+
+func TestDottedKeysProvenance(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: nested.yml}\nmy.key: literal"),
+		"nested.yml": []byte("my:\n  key: nested"),
+	}
+	reader := mapReader(files)
+
+	locs, err := SourceMap("config.yml", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]SourceLoc{
+		`my\.key`: {File: "config.yml", Line: 3, Column: 1},
+		"my.key":  {File: "nested.yml", Line: 2, Column: 3},
+	}, locs)
+
+	var ts struct {
+		Literal string `yaml:"my.key"`
+		My      struct{ Key string }
+	}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithMaxOverridesPerKey(1))
+	assert.Nil(t, err)
+	assert.Equal(t, "literal", ts.Literal)
+	assert.Equal(t, "nested", ts.My.Key)
+}
@@ -0,0 +1,104 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ProcessFileWithLeftovers processes config file and all it's imports tree like ProcessFileWithImports does,
+// and returns the keys of the merged config which don't match any field of dst, keeping their nesting
+// It helps to find the gaps between config files and the schema without `yaml:",inline"` catch-all maps
+func ProcessFileWithLeftovers(configPath string, dst interface{}, opts ...Option) (map[string]interface{}, error) {
+	if err := checkDst(dst); err != nil {
+		return nil, err
+	}
+
+	merged, err := processFile(configPath, dst, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	return leftovers(reflect.TypeOf(dst), merged), nil
+}
+
+// leftovers returns the part of the tree which doesn't match the fields of the type
+func leftovers(t reflect.Type, tree map[interface{}]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		// maps, slices and interfaces take whatever is in the config
+		return nil
+	}
+
+	fields, catchAll := fieldTypes(t)
+	if catchAll {
+		return nil
+	}
+
+	result := make(map[string]interface{})
+	for key, value := range tree {
+		name := fmt.Sprint(key)
+		fieldType, ok := fields[name]
+		if !ok {
+			result[name] = toStringKeys(value)
+			continue
+		}
+		if subtree, ok := value.(map[interface{}]interface{}); ok {
+			if nested := leftovers(fieldType, subtree); len(nested) > 0 {
+				result[name] = nested
+			}
+		}
+	}
+
+	return result
+}
+
+// fieldTypes maps the YAML keys of the struct fields to their types, inlined structs included
+// catchAll is set if the struct has inlined map, which takes all the keys without fields
+func fieldTypes(t reflect.Type) (fields map[string]reflect.Type, catchAll bool) {
+	fields = make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, inline, skip := yamlFieldKey(field)
+		if skip {
+			continue
+		}
+		if !inline {
+			fields[key] = field.Type
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Map:
+			catchAll = true
+		case reflect.Struct:
+			inlined, inlinedCatchAll := fieldTypes(field.Type)
+			for k, ft := range inlined {
+				fields[k] = ft
+			}
+			catchAll = catchAll || inlinedCatchAll
+		}
+	}
+
+	return fields, catchAll
+}
+
+// toStringKeys converts all the mappings of generic tree value to maps with string keys
+func toStringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = toStringKeys(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = toStringKeys(item)
+		}
+		return s
+	default:
+		return value
+	}
+}
@@ -0,0 +1,55 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessFileWithLeftovers(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\n" +
+			"a: config1\n" +
+			"b:\n  c: config1\n  typo: config1\n" +
+			"extra: {x: 1, w: [{z: 2}]}"),
+		"config2.yml": []byte("b:\n  d: config2\n  legacy: config2\nlabels: {any: key}\nname: config2"),
+	}
+	reader := mapReader(files)
+
+	type (
+		Common struct {
+			Name string
+		}
+		testStruct struct {
+			A      string
+			B      *struct{ C, D string }
+			Labels map[string]string
+			Common `yaml:",inline"`
+		}
+	)
+
+	var ts testStruct
+	leftovers, err := ProcessFileWithLeftovers("config1.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"b": map[string]interface{}{
+			"typo":   "config1",
+			"legacy": "config2",
+		},
+		"extra": map[string]interface{}{
+			"x": 1,
+			"w": []interface{}{map[string]interface{}{"z": 2}},
+		},
+	}, leftovers)
+	assert.Equal(t, "config1", ts.A)
+	assert.Equal(t, "config2", ts.B.D)
+	assert.Equal(t, "config2", ts.Name)
+
+	var catchAll struct {
+		A    string
+		Rest map[string]interface{} `yaml:",inline"`
+	}
+	leftovers, err = ProcessFileWithLeftovers("config1.yml", &catchAll, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Empty(t, leftovers)
+}
@@ -0,0 +1,36 @@
+package yaml
+
+// Loader processes config files with the same options, on top of the configs loaded by it's base loaders
+type Loader struct {
+	opts  []Option
+	bases []loaderBase
+}
+
+type loaderBase struct {
+	loader     *Loader
+	configPath string
+}
+
+// NewLoader creates a loader processing config files with the options
+func NewLoader(opts ...Option) *Loader {
+	return &Loader{opts: opts}
+}
+
+// WithBaseFrom makes the loader apply it's config file on top of the config fully loaded by other loader
+// from configPath, with other loader's reader and options. Several bases are loaded in the order they are added
+func (l *Loader) WithBaseFrom(other *Loader, configPath string) *Loader {
+	l.bases = append(l.bases, loaderBase{loader: other, configPath: configPath})
+
+	return l
+}
+
+// Load processes config file and all it's imports tree into dst, after all the bases are loaded into it
+func (l *Loader) Load(configPath string, dst interface{}) error {
+	for _, base := range l.bases {
+		if err := base.loader.Load(base.configPath, dst); err != nil {
+			return err
+		}
+	}
+
+	return ProcessFileWithImports(configPath, dst, l.opts...)
+}
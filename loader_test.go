@@ -0,0 +1,37 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoaderWithBaseFrom(t *testing.T) {
+	globalFiles := map[string][]byte{
+		"global.yml":   []byte("imports:\n - {resource: defaults.yml}\nregion: eu\nlimits:\n  users: 100"),
+		"defaults.yml": []byte("name: default\nlimits:\n  users: 10\n  projects: 5"),
+	}
+	tenantFiles := map[string][]byte{
+		"global.yml": []byte("region: must not be read by the tenant loader"),
+		"tenant.yml": []byte("name: acme\nlimits:\n  projects: 50"),
+	}
+	type testStruct struct {
+		Name   string
+		Region string
+		Limits struct{ Users, Projects int }
+	}
+
+	global := NewLoader(WithReader(mapReader(globalFiles)))
+	tenant := NewLoader(WithReader(mapReader(tenantFiles))).WithBaseFrom(global, "global.yml")
+
+	var ts testStruct
+	err := tenant.Load("tenant.yml", &ts)
+	assert.Nil(t, err)
+	assert.Equal(t, "acme", ts.Name)
+	assert.Equal(t, "eu", ts.Region)
+	assert.Equal(t, 100, ts.Limits.Users)
+	assert.Equal(t, 50, ts.Limits.Projects)
+
+	err = NewLoader(WithReader(mapReader(tenantFiles))).WithBaseFrom(global, "missing.yml").Load("tenant.yml", &ts)
+	assert.EqualError(t, err, "no such file")
+}
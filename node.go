@@ -0,0 +1,595 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota + 1
+	mappingNode
+	sequenceNode
+)
+
+const (
+	strTag       = "!!str"
+	binaryTag    = "!!binary"
+	boolTag      = "!!bool"
+	intTag       = "!!int"
+	floatTag     = "!!float"
+	nullTag      = "!!null"
+	timestampTag = "!!timestamp"
+	mergeTag     = "!!merge"
+)
+
+// node is a YAML value parsed with yaml.v3 and resolved as yaml.v2 decodes it, keeping the tags and the source text of scalars,
+// so it can be transformed and encoded back without changing the meaning of the values:
+// 1.10 is kept as 1.10 for string fields and a timestamp stays a timestamp.
+// A nil *node is a null value
+type node struct {
+	kind nodeKind
+	// line and column of the node in the source, 1-based, 0 for the nodes made by the library
+	line, column int
+
+	// tag and text of a scalar, tags are in the short form like !!str, !!int or !vault
+	tag  string
+	text string
+
+	// keys keeps the order of mapping keys in the source
+	keys   []interface{}
+	values map[interface{}]*node
+	// keyPos are the positions of the mapping keys in the source
+	keyPos map[interface{}]position
+
+	items []*node
+}
+
+// decodeNode decodes YAML document into node tree, an empty document is a nil node
+func decodeNode(raw []byte) (*node, error) {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	return nodeDecoder{expanding: make(map[*yamlv3.Node]bool)}.node(document.Content[0])
+}
+
+// position is the line and the column of a node in the source, 1-based
+type position struct {
+	line, column int
+}
+
+// nodeDecoder converts yaml.v3 nodes into node trees, the aliases are expanded and the merge keys are applied
+type nodeDecoder struct {
+	// expanding holds the anchored nodes of the aliases being expanded, to stop on the ones containing themselves
+	expanding map[*yamlv3.Node]bool
+}
+
+func (d nodeDecoder) node(n *yamlv3.Node) (*node, error) {
+	switch n.Kind {
+	case yamlv3.AliasNode:
+		if d.expanding[n.Alias] {
+			return nil, fmt.Errorf("yaml: line %d: anchor %q value contains itself", n.Line, n.Value)
+		}
+		d.expanding[n.Alias] = true
+		defer delete(d.expanding, n.Alias)
+		return d.node(n.Alias)
+	case yamlv3.ScalarNode:
+		tag, value, err := resolveScalar(n)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		text := n.Value
+		if tag == binaryTag {
+			text = value.(string)
+		}
+		return &node{kind: scalarNode, line: n.Line, column: n.Column, tag: tag, text: text}, nil
+	case yamlv3.SequenceNode:
+		seq := &node{kind: sequenceNode, line: n.Line, column: n.Column, items: make([]*node, 0, len(n.Content))}
+		for _, child := range n.Content {
+			item, err := d.node(child)
+			if err != nil {
+				return nil, err
+			}
+			seq.items = append(seq.items, item)
+		}
+		return seq, nil
+	default:
+		mapping := &node{
+			kind:   mappingNode,
+			line:   n.Line,
+			column: n.Column,
+			values: make(map[interface{}]*node, len(n.Content)/2),
+			keyPos: make(map[interface{}]position, len(n.Content)/2),
+		}
+		return mapping, d.mapping(mapping, n)
+	}
+}
+
+// mapping sets the keys of the yaml.v3 mapping to the node, a key set twice keeps it's first place and the last value
+func (d nodeDecoder) mapping(mapping *node, n *yamlv3.Node) error {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Kind == yamlv3.ScalarNode && n.Content[i].Tag == mergeTag {
+			if err := d.merge(mapping, n.Content[i+1]); err != nil {
+				return err
+			}
+			continue
+		}
+		key, err := decodeKey(n.Content[i])
+		if err != nil {
+			return err
+		}
+		value, err := d.node(n.Content[i+1])
+		if err != nil {
+			return err
+		}
+		mapping.set(key, value, position{line: n.Content[i].Line, column: n.Content[i].Column})
+	}
+
+	return nil
+}
+
+// merge applies the value of the << key to the mapping the way yaml.v2 does: the merged keys are set in the order
+// they come, and the earlier mappings of a sequence take precedence over the later ones
+func (d nodeDecoder) merge(mapping *node, n *yamlv3.Node) error {
+	sources := []*yamlv3.Node{n}
+	if n.Kind == yamlv3.SequenceNode {
+		sources = make([]*yamlv3.Node, 0, len(n.Content))
+		for i := len(n.Content) - 1; i >= 0; i-- {
+			sources = append(sources, n.Content[i])
+		}
+	}
+
+	for _, source := range sources {
+		target := source
+		if target.Kind == yamlv3.AliasNode {
+			target = target.Alias
+		}
+		if target.Kind != yamlv3.MappingNode {
+			return fmt.Errorf("yaml: line %d: map merge requires map or sequence of maps as the value", source.Line)
+		}
+		merged, err := d.node(source)
+		if err != nil {
+			return err
+		}
+		for _, key := range merged.keys {
+			mapping.set(key, merged.values[key], merged.keyPos[key])
+		}
+	}
+
+	return nil
+}
+
+// set sets the value of the mapping key at the position, the new keys are added after the others
+func (n *node) set(key interface{}, value *node, pos position) {
+	if _, ok := n.values[key]; !ok {
+		n.keys = append(n.keys, key)
+	}
+	n.values[key], n.keyPos[key] = value, pos
+}
+
+// shiftLines moves the positions of the node tree down by the number of lines, for the documents of a file
+func (n *node) shiftLines(lines int) {
+	_ = n.walk(func(n *node) error {
+		n.line += lines
+		for key, pos := range n.keyPos {
+			n.keyPos[key] = position{line: pos.line + lines, column: pos.column}
+		}
+		return nil
+	})
+}
+
+// decodeKey decodes the mapping key into the Go value yaml.v2 decodes it into, like int for 1 or bool for yes
+func decodeKey(n *yamlv3.Node) (interface{}, error) {
+	if n.Kind == yamlv3.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind != yamlv3.ScalarNode {
+		return nil, fmt.Errorf("yaml: line %d: invalid map key: %s", n.Line, n.ShortTag())
+	}
+	tag, value, err := resolveScalar(n)
+	if tag == timestampTag {
+		// yaml.v2 keeps the timestamps decoded into interface{} as strings
+		return n.Value, err
+	}
+
+	return value, err
+}
+
+// resolveScalar returns the tag and the Go value of the scalar, the way yaml.v2 resolves them:
+// the untagged plain scalars follow YAML 1.1, so yes, on and y are booleans, and !!binary is base64-decoded
+func resolveScalar(n *yamlv3.Node) (string, interface{}, error) {
+	if n.Style&yamlv3.TaggedStyle == 0 {
+		if n.Style != 0 {
+			// quoted and block scalars are strings
+			return strTag, n.Value, nil
+		}
+		tag, value := resolvePlain(n.Value)
+		return tag, value, nil
+	}
+
+	switch n.Tag {
+	case strTag:
+		return strTag, n.Value, nil
+	case binaryTag:
+		data, err := base64.StdEncoding.DecodeString(n.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("yaml: line %d: !!binary value contains invalid base64 data", n.Line)
+		}
+		return binaryTag, string(data), nil
+	case boolTag, intTag, floatTag, nullTag, timestampTag:
+		tag, value := resolvePlain(n.Value)
+		switch {
+		case tag == n.Tag:
+			return tag, value, nil
+		case tag == intTag && n.Tag == floatTag:
+			f, _ := strconv.ParseFloat(fmt.Sprint(value), 64)
+			return floatTag, f, nil
+		}
+		return "", nil, fmt.Errorf("yaml: line %d: cannot decode %s `%s` as a %s", n.Line, tag, n.Value, n.Tag)
+	default:
+		// the custom tags are kept with the text
+		return n.Tag, n.Value, nil
+	}
+}
+
+// plainValues are the plain scalars of YAML 1.1 resolved by yaml.v2 without parsing
+var plainValues = map[string]interface{}{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true,
+	"true": true, "True": true, "TRUE": true,
+	"on": true, "On": true, "ON": true,
+	"n": false, "N": false, "no": false, "No": false, "NO": false,
+	"false": false, "False": false, "FALSE": false,
+	"off": false, "Off": false, "OFF": false,
+	"": nil, "~": nil, "null": nil, "Null": nil, "NULL": nil,
+	".nan": math.NaN(), ".NaN": math.NaN(), ".NAN": math.NaN(),
+	".inf": math.Inf(1), ".Inf": math.Inf(1), ".INF": math.Inf(1),
+	"+.inf": math.Inf(1), "+.Inf": math.Inf(1), "+.INF": math.Inf(1),
+	"-.inf": math.Inf(-1), "-.Inf": math.Inf(-1), "-.INF": math.Inf(-1),
+}
+
+var plainFloatRe = regexp.MustCompile(`^[-+]?[0-9]*\.?[0-9]+([eE][-+][0-9]+)?$`)
+
+// resolvePlain resolves the tag and the Go value of the untagged plain scalar, as yaml.v2 does,
+// the timestamps are kept as strings
+func resolvePlain(in string) (string, interface{}) {
+	if value, ok := plainValues[in]; ok {
+		switch value.(type) {
+		case nil:
+			return nullTag, nil
+		case bool:
+			return boolTag, value
+		default:
+			return floatTag, value
+		}
+	}
+
+	switch {
+	case in[0] == '.':
+		if f, err := strconv.ParseFloat(in, 64); err == nil {
+			return floatTag, f
+		}
+	case in[0] == '+' || in[0] == '-' || (in[0] >= '0' && in[0] <= '9'):
+		if isTimestamp(in) {
+			return timestampTag, in
+		}
+		plain := strings.ReplaceAll(in, "_", "")
+		if i, err := strconv.ParseInt(plain, 0, 64); err == nil {
+			if i == int64(int(i)) {
+				return intTag, int(i)
+			}
+			return intTag, i
+		}
+		if u, err := strconv.ParseUint(plain, 0, 64); err == nil {
+			return intTag, u
+		}
+		if plainFloatRe.MatchString(plain) {
+			if f, err := strconv.ParseFloat(plain, 64); err == nil {
+				return floatTag, f
+			}
+		}
+	}
+
+	return strTag, in
+}
+
+// timestampFormats are the formats of the timestamps yaml.v2 recognizes
+var timestampFormats = []string{
+	"2006-1-2T15:4:5.999999999Z07:00",
+	"2006-1-2t15:4:5.999999999Z07:00",
+	"2006-1-2 15:4:5.999999999",
+	"2006-1-2",
+}
+
+// isTimestamp checks if the plain scalar is a timestamp, all of them start with four digits of the year and a dash
+func isTimestamp(s string) bool {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i != 4 || s[i] != '-' {
+		return false
+	}
+	for _, format := range timestampFormats {
+		if _, err := time.Parse(format, s); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeKey removes the key and it's value from the mapping
+func (n *node) removeKey(key interface{}) {
+	if _, ok := n.values[key]; !ok {
+		return
+	}
+	delete(n.values, key)
+	delete(n.keyPos, key)
+	for i, k := range n.keys {
+		if k == key {
+			n.keys = append(n.keys[:i:i], n.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// walk calls fn for the node and all it's descendants, depth-first
+func (n *node) walk(fn func(n *node) error) error {
+	if n == nil {
+		return nil
+	}
+	if err := fn(n); err != nil {
+		return err
+	}
+	for _, key := range n.keys {
+		if err := n.values[key].walk(fn); err != nil {
+			return err
+		}
+	}
+	for _, item := range n.items {
+		if err := item.walk(fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// outputStyle is the formatting of YAML written by the library
+type outputStyle struct {
+	// indent is the number of spaces every nesting level is indented by, at least 2
+	indent int
+	// blockStrings writes multi-line strings as literal block scalars, otherwise they are double-quoted
+	blockStrings bool
+	// trailingNewline ends the document with a line break
+	trailingNewline bool
+}
+
+var defaultOutputStyle = outputStyle{indent: 2, blockStrings: true, trailingNewline: true}
+
+// encodeNode encodes the node tree as a block style YAML document
+func encodeNode(n *node) []byte {
+	return defaultOutputStyle.encode(n)
+}
+
+// encode encodes the node tree as a block style YAML document formatted with the style
+func (s outputStyle) encode(n *node) []byte {
+	var buf bytes.Buffer
+	switch {
+	case n == nil:
+		buf.WriteString("null\n")
+	case isEmptyCollection(n) || n.kind == scalarNode:
+		buf.WriteString(inlineNode(n))
+		buf.WriteByte('\n')
+	default:
+		s.writeNode(&buf, n, 0)
+	}
+	if !s.trailingNewline {
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	}
+
+	return buf.Bytes()
+}
+
+func isEmptyCollection(n *node) bool {
+	return (n.kind == mappingNode && len(n.keys) == 0) || (n.kind == sequenceNode && len(n.items) == 0)
+}
+
+// inlineNode encodes the node which fits into a single line: a scalar or an empty collection
+func inlineNode(n *node) string {
+	switch {
+	case n == nil:
+		return "null"
+	case n.kind == mappingNode:
+		return "{}"
+	case n.kind == sequenceNode:
+		return "[]"
+	}
+
+	switch n.tag {
+	case strTag:
+		return encodeString(n.text)
+	case binaryTag:
+		return binaryTag + " " + base64.StdEncoding.EncodeToString([]byte(n.text))
+	case intTag, floatTag, boolTag, nullTag, timestampTag:
+		// these were plain scalars in the source, so the text is safe to write as is
+		return n.text
+	default:
+		return n.tag + " " + quoteString(n.text)
+	}
+}
+
+// writeNode writes non-empty mapping or sequence, every line is indented by indent spaces
+func (s outputStyle) writeNode(buf *bytes.Buffer, n *node, indent int) {
+	prefix := strings.Repeat(" ", indent)
+	if n.kind == sequenceNode {
+		for _, item := range n.items {
+			buf.WriteString(prefix)
+			buf.WriteByte('-')
+			s.writeValue(buf, item, indent, true)
+		}
+		return
+	}
+
+	for _, key := range n.keys {
+		buf.WriteString(prefix)
+		buf.WriteString(encodeKey(key))
+		buf.WriteByte(':')
+		s.writeValue(buf, n.values[key], indent, false)
+	}
+}
+
+// writeValue writes mapping value or sequence item after it's key or dash
+func (s outputStyle) writeValue(buf *bytes.Buffer, n *node, indent int, sequenceItem bool) {
+	if n == nil || n.kind == scalarNode || isEmptyCollection(n) {
+		if s.blockStrings && n != nil && n.kind == scalarNode && n.tag == strTag {
+			if block, ok := literalBlock(n.text, indent+s.indent); ok {
+				buf.WriteString(block)
+				return
+			}
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(inlineNode(n))
+		buf.WriteByte('\n')
+		return
+	}
+
+	if !sequenceItem {
+		buf.WriteByte('\n')
+		s.writeNode(buf, n, indent+s.indent)
+		return
+	}
+	// the first line of a collection in a sequence goes right after the dash
+	var nested bytes.Buffer
+	s.writeNode(&nested, n, indent+s.indent)
+	buf.WriteString(strings.Repeat(" ", s.indent-1))
+	buf.Write(nested.Bytes()[indent+s.indent:])
+}
+
+// literalBlock encodes multi-line string as a literal block scalar, if it can be kept exactly
+func literalBlock(s string, indent int) (string, bool) {
+	if !strings.Contains(strings.TrimRight(s, "\n"), "\n") {
+		return "", false
+	}
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	// the indentation of the block is detected by it's first non-empty line
+	indented := false
+	for _, line := range lines {
+		if line != "" && !indented {
+			if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+				return "", false
+			}
+			indented = true
+		}
+		if strings.HasSuffix(line, " ") || !isPrintable(line) {
+			return "", false
+		}
+	}
+
+	var chomping string
+	switch trailing := len(s) - len(strings.TrimRight(s, "\n")); {
+	case trailing == 0:
+		chomping = "-"
+	case trailing > 1:
+		chomping = "+"
+	}
+
+	prefix := strings.Repeat(" ", indent)
+	var buf strings.Builder
+	buf.WriteString(" |" + chomping + "\n")
+	for _, line := range lines {
+		if line != "" {
+			buf.WriteString(prefix)
+			buf.WriteString(line)
+		}
+		buf.WriteByte('\n')
+	}
+	if chomping == "+" {
+		buf.WriteString(strings.Repeat("\n", len(s)-len(strings.TrimRight(s, "\n"))-1))
+	}
+
+	return buf.String(), true
+}
+
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if r == '\r' || r == '\uFEFF' || (!unicode.IsPrint(r) && r != '\t') {
+			return false
+		}
+	}
+
+	return utf8.ValidString(s)
+}
+
+// encodeString encodes the string as a plain scalar when yaml.v2 would do the same, as a quoted one otherwise
+func encodeString(s string) string {
+	if out, err := yaml.Marshal(s); err == nil && string(out) == s+"\n" && isPrintable(s) {
+		return s
+	}
+
+	return quoteString(s)
+}
+
+// quoteString encodes the string as a double-quoted scalar
+func quoteString(s string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r == utf8.RuneError || r == '\uFEFF' || !unicode.IsPrint(r):
+			if r <= 0xFFFF {
+				fmt.Fprintf(&buf, `\u%04X`, r)
+			} else {
+				fmt.Fprintf(&buf, `\U%08X`, r)
+			}
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+
+	return buf.String()
+}
+
+// encodeKey encodes the mapping key, as it's decoded by yaml.v2
+func encodeKey(key interface{}) string {
+	switch k := key.(type) {
+	case nil:
+		return "null"
+	case string:
+		return encodeString(k)
+	case float64:
+		switch {
+		case math.IsInf(k, 1):
+			return ".inf"
+		case math.IsInf(k, -1):
+			return "-.inf"
+		case math.IsNaN(k):
+			return ".nan"
+		}
+		return strconv.FormatFloat(k, 'g', -1, 64)
+	default:
+		return fmt.Sprint(k)
+	}
+}
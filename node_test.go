@@ -0,0 +1,177 @@
+package yaml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestNodeRoundTrip(t *testing.T) {
+	type testStruct struct {
+		Version  string
+		Enabled  string
+		Hex      string
+		Hex2     int
+		Date     time.Time
+		Text     string
+		Stripped string
+		Kept     string
+		Quoted   string
+		Special  string
+		Empty    string
+		Null     *string
+		List     []interface{}
+		Nested   []map[string]interface{}
+		Matrix   [][]int
+		Any      interface{}
+		Binary   string
+		Keys     map[interface{}]string
+		Custom   string
+	}
+	src := []byte("version: 1.10\n" +
+		"enabled: on\n" +
+		"hex: 0x1F\n" +
+		"hex2: 0x1F\n" +
+		"date: 2001-12-14\n" +
+		"text: |\n  line 1\n    indented line 2\n\n  line 4\n" +
+		"stripped: |-\n  no trailing newline\n  here\n" +
+		"kept: |+\n  trailing\n  newlines\n\n\n" +
+		"quoted: \"1.10\"\n" +
+		"special: \"tab\\there: \\\"quotes\\\" # not a comment\\u2028\"\n" +
+		"empty: ''\n" +
+		"null: ~\n" +
+		"list: [1, 1.5, yes, ~, text, '2', []]\n" +
+		"nested:\n  - {a: 1, b: [x, y]}\n  - c: {}\n" +
+		"matrix: [[1, 2], [3]]\n" +
+		"any: {1: one, true: yes, 1.5: float, ~: null}\n" +
+		"binary: !!binary aGVsbG8=\n" +
+		"keys: {1: int, key with spaces: spaces, 'quoted: key': quoted}\n" +
+		"custom: !local value\n")
+
+	root, err := decodeNode(src)
+	assert.Nil(t, err)
+	encoded := encodeNode(root)
+
+	var direct, roundTrip testStruct
+	assert.Nil(t, yaml.Unmarshal(src, &direct))
+	assert.Nil(t, yaml.Unmarshal(encoded, &roundTrip), string(encoded))
+	assert.Equal(t, direct, roundTrip, string(encoded))
+	assert.Equal(t, "1.10", roundTrip.Version)
+	assert.Equal(t, "on", roundTrip.Enabled)
+	assert.Equal(t, "line 1\n  indented line 2\n\nline 4\n", roundTrip.Text)
+
+	var directGeneric, roundTripGeneric interface{}
+	assert.Nil(t, yaml.Unmarshal(src, &directGeneric))
+	assert.Nil(t, yaml.Unmarshal(encoded, &roundTripGeneric))
+	assert.Equal(t, directGeneric, roundTripGeneric)
+}
+
+func TestNodeTagsAndLines(t *testing.T) {
+	root, err := decodeNode([]byte("a: 1\nb:\n  c: !vault secret/path#field\n  d: [x, 'y']\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, root.keys)
+	assert.Equal(t, "!!int", root.values["a"].tag)
+	assert.Equal(t, 1, root.values["a"].line)
+
+	c := root.values["b"].values["c"]
+	assert.Equal(t, "!vault", c.tag)
+	assert.Equal(t, "secret/path#field", c.text)
+	assert.Equal(t, 3, c.line)
+
+	d := root.values["b"].values["d"]
+	assert.Equal(t, sequenceNode, d.kind)
+	assert.Equal(t, 4, d.line)
+	assert.Equal(t, "!!str", d.items[1].tag)
+
+	empty, err := decodeNode([]byte("# nothing here"))
+	assert.Nil(t, err)
+	assert.Nil(t, empty)
+}
+
+func TestNodeMergeKeys(t *testing.T) {
+	src := []byte("base: &base {host: localhost, port: 80}\n" +
+		"extra: &extra {port: 8080, debug: on}\n" +
+		"single: {<<: *base, name: single}\n" +
+		"list:\n  <<: [*extra, *base]\n  name: list\n" +
+		"override: {name: override, <<: *base, host: example.com}\n")
+	root, err := decodeNode(src)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []interface{}{"host", "port", "name"}, root.values["single"].keys)
+	assert.Equal(t, []interface{}{"host", "port", "debug", "name"}, root.values["list"].keys)
+	assert.Equal(t, "8080", root.values["list"].values["port"].text)
+	assert.Equal(t, 1, root.values["single"].values["host"].line, "merged values keep the lines of the anchor")
+
+	var direct, roundTrip interface{}
+	assert.Nil(t, yaml.Unmarshal(src, &direct))
+	assert.Nil(t, yaml.Unmarshal(encodeNode(root), &roundTrip), string(encodeNode(root)))
+	assert.Equal(t, direct, roundTrip)
+
+	_, err = decodeNode([]byte("a: &a [*a]"))
+	assert.NotNil(t, err)
+	_, err = decodeNode([]byte("a: {<<: [1]}"))
+	assert.NotNil(t, err)
+}
+
+func TestNodeNullStrings(t *testing.T) {
+	src := []byte("a: 'null'\nb: \"~\"\nc: !!str null\nd: ~\nlist: [x, 'null', ~, \"~\", !!str null]\nnested: {e: 'null'}\n")
+	root, err := decodeNode(src)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []interface{}{"a", "b", "c", "d", "list", "nested"}, root.keys)
+	assert.Equal(t, &node{kind: scalarNode, line: 1, column: 4, tag: strTag, text: "null"}, root.values["a"])
+	assert.Equal(t, "~", root.values["b"].text)
+	assert.Equal(t, 2, root.values["b"].line)
+	assert.Equal(t, "null", root.values["c"].text)
+	assert.Nil(t, root.values["d"])
+	assert.Equal(t, "null", root.values["nested"].values["e"].text)
+
+	var direct, roundTrip interface{}
+	assert.Nil(t, yaml.Unmarshal(src, &direct))
+	assert.Nil(t, yaml.Unmarshal(encodeNode(root), &roundTrip), string(encodeNode(root)))
+	assert.Equal(t, direct, roundTrip)
+	assert.Equal(t, []interface{}{"x", "null", nil, "~", "null"}, roundTrip.(map[interface{}]interface{})["list"])
+
+	scalar, err := decodeNode([]byte("'null'"))
+	assert.Nil(t, err)
+	assert.Equal(t, &node{kind: scalarNode, line: 1, column: 1, tag: strTag, text: "null"}, scalar)
+}
+
+func TestProcessNullStrings(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: base.yml}\nname: 'null'\n"),
+		"base.yml":   []byte("password: \"~\"\nmode: !!str null\n"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name     string
+		Password string
+		Mode     string
+	}
+	expected := testStruct{Name: "null", Password: "~", Mode: "null"}
+
+	for name, opts := range map[string][]Option{
+		"trim":        {WithTrimStringValues()},
+		"bool string": {WithPreserveBoolStrings()},
+		"coercion":    {WithTypeCoercion()},
+		"secrets":     {WithSecretResolver(fakeSecretResolver{})},
+	} {
+		var ts testStruct
+		err := ProcessFileWithImports("config.yml", &ts, append(opts, WithReader(reader))...)
+		assert.Nil(t, err, name)
+		assert.Equal(t, expected, ts, name)
+	}
+
+	config := map[string]interface{}{}
+	assert.Nil(t, ProcessFileWithImports("config.yml", &config, WithReader(reader)))
+	assert.Equal(t, map[string]interface{}{"name": "null", "password": "~", "mode": "null"}, config)
+
+	locs, err := SourceMap("config.yml", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, SourceLoc{File: "config.yml", Line: 3, Column: 1}, locs["name"])
+	assert.Equal(t, SourceLoc{File: "base.yml", Line: 1, Column: 1}, locs["password"])
+}
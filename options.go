@@ -0,0 +1,441 @@
+package yaml
+
+import (
+	"context"
+	"io/ioutil"
+	"time"
+)
+
+type (
+	// Option changes the way config file and it's imports tree are processed
+	Option func(*options)
+
+	options struct {
+		reader               ReadFileFunc
+		customReader         bool
+		typedReader          bool
+		stat                 StatFunc
+		glob                 GlobFunc
+		nonEmptyValidation   bool
+		result               *Result
+		jsonNumbers          bool
+		maxOverridesPerKey   int
+		secretResolver       SecretResolver
+		trimStringValues     bool
+		readTimeout          time.Duration
+		strict               bool
+		envSubstitution      bool
+		envLookup            func(name string) (string, bool)
+		strictSubstitution   bool
+		typeCoercion         bool
+		maxImportsPerFile    int
+		templateData         interface{}
+		warnRedundantImports bool
+		warnShapeMismatch    bool
+		aggregateErrors      bool
+		errorFormatter       func([]error) string
+		preserveBoolStrings  bool
+		requiredFiles        []string
+		sectionsKey          string
+		activeSection        string
+		envBinding           bool
+		outputStyle          *outputStyle
+		ignoreAllErrors      bool
+		encoding             Encoding
+		preflightValidation  bool
+		maxCacheBytes        int
+		parallelReads        int
+		appendSlices         bool
+		maxImportDepth       int
+		maxImports           int
+		ctx                  context.Context
+		contextReader        ContextReadFileFunc
+		validate             func(dst interface{}) error
+		optionalRoot         bool
+		onIgnoredError       func(resource string, err error)
+		extensionFallback    bool
+		defaults             func() interface{}
+		importsDisabled      bool
+		importsKey           string
+		duplicateKeysCheck   bool
+		// rootRaw is the content of the base config supplied by the caller instead of reading it
+		rootRaw []byte
+		// slashPaths resolves the imports with forward-slash paths semantics, as fs.FS paths are
+		slashPaths bool
+		// importParents receives the index of the importing file for every discovered import, -1 for the base file
+		importParents *[]int
+		// importEdges receives the importer and the imported file indexes of every import, including the ones
+		// of the files imported several times, in the order of declaration of every importer
+		importEdges *[]ImportEdge
+	}
+)
+
+const (
+	// defaultMaxImportDepth is generous, the chains deeper than that are likely generated by mistake
+	defaultMaxImportDepth = 50
+	// defaultMaxImports is generous as well, it protects from the runaway fan-out of the imports
+	defaultMaxImports = 1000
+	// defaultImportsKey is the key of the imports directive, as Symfony names it
+	defaultImportsKey = "imports"
+)
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		reader:         ioutil.ReadFile,
+		maxImportDepth: defaultMaxImportDepth,
+		maxImports:     defaultMaxImports,
+		importsKey:     defaultImportsKey,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithReader sets the function used to read config file and all it's imports, ioutil.ReadFile by default
+// The reader reports the missing files with the errors matching os.ErrNotExist, the imports failing with other
+// errors are reported as ImportReadErr
+// It replaces the reader set by WithTypedReader or WithContextReader before it, the last of them is used
+func WithReader(reader ReadFileFunc) Option {
+	return func(o *options) {
+		o.reader = reader
+		o.contextReader = nil
+		o.customReader = true
+		o.typedReader = false
+	}
+}
+
+// WithStat sets the function used to check that the base config is a regular file
+// os.Stat is used with the default reader, the check is skipped for custom readers without stat function
+func WithStat(stat StatFunc) Option {
+	return func(o *options) {
+		o.stat = stat
+	}
+}
+
+// WithNonEmptyValidation enables the check of string fields tagged with `config:"nonempty"`:
+// processing fails if such a field is present in the config files, but it's empty after all the files are merged
+func WithNonEmptyValidation() Option {
+	return func(o *options) {
+		o.nonEmptyValidation = true
+	}
+}
+
+// WithValidate sets the function validating dst once all the files are merged into it, like Struct method
+// of go-playground/validator, the error of it is returned as is. ProcessFragmentDir validates dst once,
+// after the last fragment, and the functions computing checksums don't validate their internal destination
+func WithValidate(validate func(dst interface{}) error) Option {
+	return func(o *options) {
+		o.validate = validate
+	}
+}
+
+// WithResult makes processing fill r with details on which files were loaded or skipped
+func WithResult(r *Result) Option {
+	return func(o *options) {
+		o.result = r
+	}
+}
+
+// WithJSONNumbers makes numbers decoded into interface{} values to be json.Number keeping the exact source text,
+// so big integers and precise decimals don't lose precision on the way through float64.
+// Fields of interface{}, []interface{} and map[string]interface{} types are filled from the merged tree of all files
+func WithJSONNumbers() Option {
+	return func(o *options) {
+		o.jsonNumbers = true
+	}
+}
+
+// WithMaxOverridesPerKey makes processing fail if any key is set by more than n files of the imports tree.
+// It's a detector of accidentally over-layered configs, disabled by default
+func WithMaxOverridesPerKey(n int) Option {
+	return func(o *options) {
+		o.maxOverridesPerKey = n
+	}
+}
+
+// WithSecretResolver makes the values tagged with !vault to be replaced with secrets from the resolver
+func WithSecretResolver(resolver SecretResolver) Option {
+	return func(o *options) {
+		o.secretResolver = resolver
+	}
+}
+
+// WithTrimStringValues makes leading and trailing whitespace of all string values to be trimmed before decoding
+// It's opt-in, as it changes intentional whitespace too, like the trailing newline of block scalars
+func WithTrimStringValues() Option {
+	return func(o *options) {
+		o.trimStringValues = true
+	}
+}
+
+// WithReadTimeout limits the time of reading every file of the imports tree, slow reads fail with ReadTimeoutErr
+// The timeout of an import can be overridden with it's timeout field
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = timeout
+	}
+}
+
+// WithStrict turns the warnings about likely mistakes into errors and rejects the keys unknown to struct dst
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithEnvSubstitution replaces $NAME, ${NAME} and ${NAME:-default} in config files with environment variable values
+// before the files are parsed, undefined variables without default are replaced with empty string
+func WithEnvSubstitution() Option {
+	return func(o *options) {
+		o.envSubstitution = true
+	}
+}
+
+// WithEnvExpansion is the alias of WithEnvSubstitution
+func WithEnvExpansion() Option {
+	return WithEnvSubstitution()
+}
+
+// WithEnvLookup enables environment variable substitution with the values returned by lookup instead of os.LookupEnv
+func WithEnvLookup(lookup func(name string) (string, bool)) Option {
+	return func(o *options) {
+		o.envSubstitution = true
+		o.envLookup = lookup
+	}
+}
+
+// WithStrictSubstitution enables environment variable substitution, and fails processing with UndefinedVariableErr
+// listing all the undefined variables referenced in the imports tree
+func WithStrictSubstitution() Option {
+	return func(o *options) {
+		o.envSubstitution = true
+		o.strictSubstitution = true
+	}
+}
+
+// WithTypeCoercion converts string values to the types of the fields they are decoded into:
+// "8080" is decoded into int field, and "yes" or "1" into bool one. Values which can't be converted fail processing
+func WithTypeCoercion() Option {
+	return func(o *options) {
+		o.typeCoercion = true
+	}
+}
+
+// WithMaxImportsPerFile limits the number of imports declared by a single file, processing fails if any file
+// declares more. Zero means no limit
+func WithMaxImportsPerFile(n int) Option {
+	return func(o *options) {
+		o.maxImportsPerFile = n
+	}
+}
+
+// WithMaxImportDepth limits the depth of the import chains, the imports of the base config are at depth 1
+// Processing fails with MaxImportDepthExceededErr if the limit is exceeded, it's 50 by default, 0 disables the limit
+func WithMaxImportDepth(n int) Option {
+	return func(o *options) {
+		o.maxImportDepth = n
+	}
+}
+
+// WithMaxImports limits the number of files of the imports tree, the base config included, the files imported
+// several times are counted once. Processing fails with TooManyImportsErr if the limit is exceeded, it's 1000
+// by default, 0 disables the limit
+func WithMaxImports(n int) Option {
+	return func(o *options) {
+		o.maxImports = n
+	}
+}
+
+// WithMaxDepth is the alias of WithMaxImportDepth
+func WithMaxDepth(n int) Option {
+	return WithMaxImportDepth(n)
+}
+
+// WithTemplateData renders every config file as text/template with the data before it's parsed
+// Missing keys of map data fail the rendering
+func WithTemplateData(data interface{}) Option {
+	return func(o *options) {
+		o.templateData = data
+	}
+}
+
+// WithWarnRedundantImports adds a warning to the result for every import which sets only the values
+// already loaded from the deeper imports, so removing it changes nothing
+func WithWarnRedundantImports() Option {
+	return func(o *options) {
+		o.warnRedundantImports = true
+	}
+}
+
+// WithWarnShapeMismatch adds a warning to the result for every value which overrides the loaded one of another shape:
+// a mapping set over a scalar or a list, or a scalar or a list set over a mapping. Both files are named in the warning
+func WithWarnShapeMismatch() Option {
+	return func(o *options) {
+		o.warnShapeMismatch = true
+	}
+}
+
+// WithAggregateErrors makes processing go on after an import fails to load, and return ImportErrors with the errors
+// of all the failed imports at the end. The values of the imports which were loaded are still set to dst
+func WithAggregateErrors() Option {
+	return func(o *options) {
+		o.aggregateErrors = true
+	}
+}
+
+// WithErrorFormatter sets the function rendering the message of ImportErrors, it's used only in aggregation mode
+func WithErrorFormatter(format func([]error) string) Option {
+	return func(o *options) {
+		o.errorFormatter = format
+	}
+}
+
+// WithPreserveBoolStrings keeps yes, no, on, off, y and n values as strings, when they are decoded into
+// interface values, like the values of map[string]interface{} fields, instead of turning them into booleans
+func WithPreserveBoolStrings() Option {
+	return func(o *options) {
+		o.preserveBoolStrings = true
+	}
+}
+
+// WithRequireFiles fails processing if any of the files is not in the imports tree, or fails to load with ignore_errors
+// Relative paths are resolved against the directory of the base config
+func WithRequireFiles(paths ...string) Option {
+	return func(o *options) {
+		o.requiredFiles = append(o.requiredFiles, paths...)
+	}
+}
+
+// WithActiveSection merges the section of the topKey mapping to the root of the merged config, overriding
+// the root values, and drops the topKey mapping. The config is kept as is if there is no such section
+func WithActiveSection(topKey, name string) Option {
+	return func(o *options) {
+		o.sectionsKey, o.activeSection = topKey, name
+	}
+}
+
+// WithEnvBinding overrides the fields with `env` tag, like `yaml:"host" env:"DB_HOST"`, with the values
+// of the environment variables after the files are merged. Unset variables keep the loaded values
+func WithEnvBinding() Option {
+	return func(o *options) {
+		o.envBinding = true
+	}
+}
+
+// WithOutputStyle sets the formatting of the merged config returned by ProcessFileWithRaw
+// ProcessFileWithRaw fails with InvalidOutputStyleErr if the style is invalid
+func WithOutputStyle(style OutputStyle) Option {
+	return func(o *options) {
+		indent := style.Indent
+		if indent == 0 {
+			indent = defaultOutputStyle.indent
+		}
+		o.outputStyle = &outputStyle{indent: indent, blockStrings: !style.QuotedStrings, trailingNewline: !style.NoTrailingNewline}
+	}
+}
+
+// WithIgnoreAllErrors makes all the imports ignore errors, as if they had ignore_errors: true
+// The imports listed in required_imports still fail processing if they can't be loaded
+func WithIgnoreAllErrors() Option {
+	return func(o *options) {
+		o.ignoreAllErrors = true
+	}
+}
+
+// WithOptionalRoot makes the missing base config leave dst as is instead of failing processing, like an optional
+// local override does. Any read error of custom readers means the file is missing, the default reader's errors
+// other than not found are returned. The base config which is present, but malformed, still fails processing
+func WithOptionalRoot() Option {
+	return func(o *options) {
+		o.optionalRoot = true
+	}
+}
+
+// WithEncoding sets the character encoding of the config files, they are decoded to UTF-8 before parsing
+// By default UTF-16 files are detected by the byte order mark, and the other files are read as UTF-8
+func WithEncoding(enc Encoding) Option {
+	return func(o *options) {
+		o.encoding = enc
+	}
+}
+
+// WithPreflightValidation checks that all the imports of the tree can be read and parsed before loading any of them,
+// and fails with ImportErrors listing all the failed imports, so dst is not changed. Imports with ignore_errors
+// and the ones whose conditions are not met are not checked
+func WithPreflightValidation() Option {
+	return func(o *options) {
+		o.preflightValidation = true
+	}
+}
+
+// WithMaxCacheBytes limits the size of the files contents kept after the imports discovery, so the files are loaded
+// without reading them again. The files which don't fit into the limit are read again to be loaded
+func WithMaxCacheBytes(n int) Option {
+	return func(o *options) {
+		o.maxCacheBytes = n
+	}
+}
+
+// WithParallelReads reads the files of every level of the imports tree with the workers concurrently,
+// the reader must be safe for concurrent use. The files are merged in the order of the tree anyway,
+// so the result is the same as with sequential reads. The files which are not cached after the discovery are read
+// again by the workers ahead of loading
+func WithParallelReads(workers int) Option {
+	return func(o *options) {
+		o.parallelReads = workers
+	}
+}
+
+// WithAppendSlices appends the values of the slice fields of struct dst set by every file to the values loaded
+// from the files applied before it, instead of replacing them. Nested structs are merged the same way
+func WithAppendSlices() Option {
+	return func(o *options) {
+		o.appendSlices = true
+	}
+}
+
+// WithOnIgnoredError calls the callback for every import with ignore_errors which was skipped because it couldn't be
+// read or parsed, with the resource of the import and the error
+func WithOnIgnoredError(callback func(resource string, err error)) Option {
+	return func(o *options) {
+		o.onIgnoredError = callback
+	}
+}
+
+// WithExtensionFallback reads the imports which are not found with the alternate extension: foo.yaml instead
+// of foo.yml, and vice versa, before failing or ignoring the errors. Parse errors are never retried
+func WithExtensionFallback() Option {
+	return func(o *options) {
+		o.extensionFallback = true
+	}
+}
+
+// WithImportsDisabled loads the config file alone, the imports directives are the values of the file like any other
+// keys, so dst can have the field for the imports key
+func WithImportsDisabled() Option {
+	return func(o *options) {
+		o.importsDisabled = true
+	}
+}
+
+// WithImportsKey sets the key of the imports directive instead of imports, like include or extends:
+// the required and inherit directives are named after it, like required_include and inherit_include
+func WithImportsKey(key string) Option {
+	return func(o *options) {
+		o.importsKey = key
+	}
+}
+
+// directives returns the keys of the loader instructions, there are none if the imports are disabled
+func (o *options) directives() []string {
+	switch {
+	case o.importsDisabled:
+		return nil
+	case o.importsKey != defaultImportsKey:
+		return importsDirectives(o.importsKey)
+	}
+
+	return directiveKeys
+}
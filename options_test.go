@@ -0,0 +1,160 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithReader(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\na: from config1"),
+		"config2.yml": []byte("a: from config2\nb: from config2"),
+	}
+	reader := mapReader(files)
+
+	var ts struct {
+		A string
+		B string
+	}
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "from config1", ts.A)
+	assert.Equal(t, "from config2", ts.B)
+}
+
+func TestComposedOptions(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\na: ${OPTIONS_TEST_A}"),
+		"config2.yml": []byte("imports:\n - {resource: config3.yml}\nb: from config2"),
+		"config3.yml": []byte("c: from config3"),
+		"unknown.yml": []byte("imports:\n - {resource: config2.yml}\nd: from unknown"),
+	}
+	reader := mapReader(files)
+	t.Setenv("OPTIONS_TEST_A", "from env")
+	type config struct {
+		A string
+		B string
+		C string
+	}
+
+	var ts config
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithStrict(), WithEnvExpansion())
+	assert.Nil(t, err)
+	assert.Equal(t, config{A: "from env", B: "from config2", C: "from config3"}, ts)
+
+	ts = config{}
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "${OPTIONS_TEST_A}", ts.A, "zero options keep the values as is")
+
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithEnvExpansion(), WithMaxDepth(1))
+	assert.True(t, errors.Is(err, MaxImportDepthExceededErr))
+	assert.Nil(t, ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithMaxDepth(2)))
+
+	err = ProcessFileWithImports("unknown.yml", &ts, WithReader(reader), WithStrict(), WithMaxDepth(2))
+	assert.NotNil(t, err, "strict mode rejects the key unknown to the struct")
+	assert.Nil(t, ProcessFileWithImports("unknown.yml", &ts, WithReader(reader), WithMaxDepth(2)))
+}
+
+func TestWithImportsDisabled(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: missing.yml}\ninherit_imports: true\nname: app"),
+	}
+	reader := mapReader(files)
+	type config struct {
+		Imports []struct {
+			Resource string
+		}
+		InheritImports bool `yaml:"inherit_imports"`
+		Name           string
+	}
+
+	var ts config
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithImportsDisabled(), WithStrict())
+	assert.Nil(t, err)
+	assert.Len(t, ts.Imports, 1)
+	assert.Equal(t, "missing.yml", ts.Imports[0].Resource, "imports key is the value of the file")
+	assert.True(t, ts.InheritImports)
+	assert.Equal(t, "app", ts.Name)
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ImportNotFoundErr), "imports are enabled by default")
+
+	m := map[string]interface{}{}
+	raw, err := ProcessFileWithRaw("config.yml", &m, WithReader(reader), WithImportsDisabled())
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{map[interface{}]interface{}{"resource": "missing.yml"}}, m["imports"])
+	assert.Contains(t, string(raw), "resource: missing.yml")
+
+	var unknown struct{ Name string }
+	err = ProcessFileWithImports("config.yml", &unknown, WithReader(reader), WithImportsDisabled(), WithStrict())
+	assert.NotNil(t, err, "imports key is unknown to the struct in strict mode")
+}
+
+func TestWithImportsKey(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml":   []byte("include:\n - {resource: service.yml}\n - base.yml\nimports: [data]\nname: app"),
+		"service.yml":  []byte("inherit_include: true\ninclude:\n - {resource: defaults.yml}\nport: 8080"),
+		"base.yml":     []byte("required_include: [defaults.yml]\nname: base\nhost: localhost"),
+		"defaults.yml": []byte("port: 80\nuser: admin"),
+		"default.yml":  []byte("imports:\n - {resource: base.yml}\ninclude: [data]"),
+		"invalid.yml":  []byte("include: base.yml"),
+	}
+	reader := mapReader(files)
+	type config struct {
+		Imports []string
+		Name    string
+		Host    string
+		Port    int
+		User    string
+	}
+
+	var ts config
+	result := Result{}
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithImportsKey("include"), WithStrict(), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, config{Imports: []string{"data"}, Name: "app", Host: "localhost", Port: 8080, User: "admin"}, ts)
+	assert.ElementsMatch(t, []string{"config.yml", "service.yml", "base.yml", "defaults.yml"}, result.Loaded)
+
+	m := map[string]interface{}{}
+	err = ProcessFileWithImports("config.yml", &m, WithReader(reader), WithImportsKey("include"))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"data"}, m["imports"], "imports key is the value of the file")
+	assert.NotContains(t, m, "include")
+	assert.NotContains(t, m, "inherit_include")
+
+	var defaultKey struct {
+		Include []string
+		Name    string
+		Host    string
+	}
+	err = ProcessFileWithImports("default.yml", &defaultKey, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"data"}, defaultKey.Include, "imports key is the default")
+	assert.Equal(t, "base", defaultKey.Name)
+	assert.Equal(t, "localhost", defaultKey.Host)
+
+	err = ProcessFileWithImports("invalid.yml", &ts, WithReader(reader), WithImportsKey("include"))
+	assert.True(t, errors.Is(err, InvalidImportsErr))
+	assert.EqualError(t, err, "invalid.yml: include must be a list of resources: line 1: cannot unmarshal !!str `base.yml`",
+		"the message names the imports key")
+
+	files["sections.yml"] = []byte("environments:\n  prod:\n    include: [prod.yml]\n    imports: [data]\n    name: prod")
+	m = map[string]interface{}{}
+	err = ProcessFileWithImports("sections.yml", &m, WithReader(reader), WithImportsKey("include"), WithActiveSection("environments", "prod"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "prod", "imports": []interface{}{"data"}}, m, "the sections skip the imports key")
+
+	m = map[string]interface{}{}
+	err = ApplyOverride(&m, map[string]interface{}{"include": []string{"x.yml"}, "imports": "data"}, WithImportsKey("include"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"imports": "data"}, m, "the override skips the imports key")
+
+	locs, err := SourceMap("config.yml", reader, WithImportsKey("include"))
+	assert.Nil(t, err)
+	assert.Contains(t, locs, "imports", "imports key is the value of the file")
+	assert.NotContains(t, locs, "include")
+	assert.Equal(t, SourceLoc{File: "defaults.yml", Line: 2, Column: 1}, locs["user"])
+}
@@ -0,0 +1,40 @@
+package yaml
+
+import (
+	"context"
+	"io/ioutil"
+)
+
+type overlayKey struct{}
+
+// ContextWithOverlay returns a copy of ctx carrying in-memory files, which are read by ContextReader instead of real ones
+// Files of the overlay already carried by ctx are kept unless they are replaced by the new ones
+func ContextWithOverlay(ctx context.Context, files map[string][]byte) context.Context {
+	overlay := make(map[string][]byte, len(files))
+	if parent, ok := ctx.Value(overlayKey{}).(map[string][]byte); ok {
+		for name, data := range parent {
+			overlay[name] = data
+		}
+	}
+	for name, data := range files {
+		overlay[name] = data
+	}
+
+	return context.WithValue(ctx, overlayKey{}, overlay)
+}
+
+// ContextReader returns a reader of the files from the overlay carried by ctx
+// Files absent in the overlay are read with fallback reader, ioutil.ReadFile if it's nil
+func ContextReader(ctx context.Context, fallback ReadFileFunc) ReadFileFunc {
+	if fallback == nil {
+		fallback = ioutil.ReadFile
+	}
+	overlay, _ := ctx.Value(overlayKey{}).(map[string][]byte)
+
+	return func(filename string) ([]byte, error) {
+		if data, ok := overlay[filename]; ok {
+			return data, nil
+		}
+		return fallback(filename)
+	}
+}
@@ -0,0 +1,41 @@
+package yaml
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "config.yml")
+	overriddenPath := filepath.Join(dir, "db.yml")
+	if err := ioutil.WriteFile(configPath, []byte("imports:\n - {resource: db.yml}\nname: from disk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(overriddenPath, []byte("db: from disk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWithOverlay(context.Background(), map[string][]byte{overriddenPath: []byte("db: from first overlay")})
+	ctx = ContextWithOverlay(ctx, map[string][]byte{overriddenPath: []byte("db: from overlay")})
+
+	var ts struct{ Name, DB string }
+	err = ProcessFileWithImports(configPath, &ts, WithReader(ContextReader(ctx, nil)))
+	assert.Nil(t, err)
+	assert.Equal(t, "from disk", ts.Name)
+	assert.Equal(t, "from overlay", ts.DB)
+
+	err = ProcessFileWithImports(configPath, &ts, WithReader(ContextReader(context.Background(), nil)))
+	assert.Nil(t, err)
+	assert.Equal(t, "from disk", ts.DB)
+}
@@ -0,0 +1,19 @@
+package yaml
+
+import "gopkg.in/yaml.v2"
+
+// ApplyOverride merges the override into already loaded dst the same way an imported file is merged:
+// nested mappings are merged key by key, any other values, including lists, override the ones in dst
+// The imports directives of the override are skipped, the options naming them are the ones of the files loaded
+func ApplyOverride(dst interface{}, override map[string]interface{}, opts ...Option) error {
+	if err := checkDst(dst); err != nil {
+		return err
+	}
+
+	raw, err := yaml.Marshal(override)
+	if err != nil {
+		return err
+	}
+
+	return decodeDocument(raw, dst, false, newOptions(opts).directives())
+}
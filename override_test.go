@@ -0,0 +1,50 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyOverride(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: db.yml}\nname: app\nlabels: {team: core, tier: backend}\nhosts: [a, b]"),
+		"db.yml":     []byte("db:\n  host: localhost\n  port: 5432\n  pool: {size: 10, idle: 2}"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+			Pool struct{ Size, Idle int }
+		}
+		Labels map[string]string
+		Hosts  []string
+	}
+
+	var ts testStruct
+	assert.Nil(t, ProcessFileWithImports("config.yml", &ts, WithReader(reader)))
+
+	err := ApplyOverride(&ts, map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "replica",
+			"pool": map[string]interface{}{"size": 20},
+		},
+		"labels": map[string]interface{}{"tier": "frontend"},
+		"hosts":  []string{"c"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "replica", ts.DB.Host)
+	assert.Equal(t, 5432, ts.DB.Port)
+	assert.Equal(t, 20, ts.DB.Pool.Size)
+	assert.Equal(t, 2, ts.DB.Pool.Idle)
+	assert.Equal(t, map[string]string{"team": "core", "tier": "frontend"}, ts.Labels)
+	assert.Equal(t, []string{"c"}, ts.Hosts)
+
+	err = ApplyOverride(&ts, map[string]interface{}{"db": map[string]interface{}{"port": "not a number"}})
+	assert.NotNil(t, err)
+
+	assert.Equal(t, WrongDstTypeErr, ApplyOverride(ts, nil))
+}
@@ -0,0 +1,100 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var TooManyOverridesErr = errors.New("key is set by too many files")
+
+// overrideLog maps the dotted path of every leaf key to the files which set it, in apply order
+type overrideLog map[string][]string
+
+// record logs all leaf keys of the file tree as set by the resource
+func (l overrideLog) record(tree map[interface{}]interface{}, path string, resource string) {
+	for key, value := range tree {
+		keyPath := joinKeyPath(path, key)
+		if subtree, ok := value.(map[interface{}]interface{}); ok && len(subtree) > 0 {
+			l.record(subtree, keyPath, resource)
+			continue
+		}
+		l[keyPath] = append(l[keyPath], resource)
+	}
+}
+
+// check returns an error for the first key, in sorted order, set by more than max files
+func (l overrideLog) check(max int) error {
+	paths := make([]string, 0, len(l))
+	for path := range l {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if files := l[path]; len(files) > max {
+			return fmt.Errorf("%s is set by %d files (%s), limit is %d: %w",
+				path, len(files), strings.Join(files, ", "), max, TooManyOverridesErr)
+		}
+	}
+
+	return nil
+}
+
+// shapeConflicts returns the warnings about the values of the file tree which change the shape of the merged ones:
+// a mapping set over a scalar or a list, or a scalar or a list set over a mapping. Such a value replaces the merged one
+// instead of being merged into it, so the keys of the mapping are dropped, or the override has no effect
+// on the fields which can't hold the new shape
+func (l overrideLog) shapeConflicts(merged, tree map[interface{}]interface{}, path, resource string) []string {
+	var warnings []string
+	for key, value := range tree {
+		existing, present := merged[key]
+		if !present || existing == nil || value == nil {
+			continue
+		}
+		keyPath := joinKeyPath(path, key)
+		valueMap, valueIsMap := value.(map[interface{}]interface{})
+		existingMap, existingIsMap := existing.(map[interface{}]interface{})
+		switch {
+		case valueIsMap && existingIsMap:
+			warnings = append(warnings, l.shapeConflicts(existingMap, valueMap, keyPath, resource)...)
+		case valueIsMap != existingIsMap:
+			warnings = append(warnings, fmt.Sprintf("%s: %s sets %s over %s set by %s, the value is replaced instead of merged",
+				keyPath, resource, valueShape(value), valueShape(existing), strings.Join(l.setBy(keyPath), ", ")))
+		}
+	}
+	sort.Strings(warnings)
+
+	return warnings
+}
+
+// setBy returns the files which set the value at the path last, for a mapping they are the files setting it's keys
+func (l overrideLog) setBy(path string) []string {
+	if files := l[path]; len(files) > 0 {
+		return files[len(files)-1:]
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for keyPath, keyFiles := range l {
+		if strings.HasPrefix(keyPath, path+".") && !seen[keyFiles[len(keyFiles)-1]] {
+			seen[keyFiles[len(keyFiles)-1]] = true
+			files = append(files, keyFiles[len(keyFiles)-1])
+		}
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+func valueShape(value interface{}) string {
+	switch value.(type) {
+	case map[interface{}]interface{}:
+		return "mapping"
+	case []interface{}:
+		return "list"
+	default:
+		return "scalar"
+	}
+}
@@ -0,0 +1,56 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMaxOverridesPerKey(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\na: config1\nb:\n  c: config1"),
+		"config2.yml": []byte("imports:\n - {resource: config3.yml}\nb:\n  c: config2"),
+		"config3.yml": []byte("a: config3\nb:\n  c: config3\n  d: config3"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		A string
+		B struct{ C, D string }
+	}
+
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithMaxOverridesPerKey(2))
+	assert.True(t, errors.Is(err, TooManyOverridesErr))
+	assert.EqualError(t, err, "b.c is set by 3 files (config3.yml, config2.yml, config1.yml), limit is 2: "+
+		"key is set by too many files")
+
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithMaxOverridesPerKey(3))
+	assert.Nil(t, err)
+
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader))
+	assert.Nil(t, err, "the check must be disabled by default")
+}
+
+func TestWithWarnShapeMismatch(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\nfeatures:\n  beta: true\nlog:\n  level: info"),
+		"config2.yml": []byte("imports:\n - {resource: config3.yml}\nfeatures: off\nhosts: {primary: db1}"),
+		"config3.yml": []byte("features:\n  alpha: true\nhosts: [db1, db2]\nlog:\n  level: debug\n  format: json"),
+	}
+	reader := mapReader(files)
+
+	var dst map[string]interface{}
+	result := Result{}
+	err := ProcessFileWithImports("config1.yml", &dst, WithReader(reader), WithResult(&result), WithWarnShapeMismatch())
+	assert.Nil(t, err)
+	assert.Equal(t, map[interface{}]interface{}{"beta": true}, dst["features"], "alpha set by config3.yml is dropped")
+	assert.Equal(t, []string{
+		"features: config2.yml sets scalar over mapping set by config3.yml, the value is replaced instead of merged",
+		"hosts: config2.yml sets mapping over list set by config3.yml, the value is replaced instead of merged",
+		"features: config1.yml sets mapping over scalar set by config2.yml, the value is replaced instead of merged",
+	}, result.Warnings)
+
+	err = ProcessFileWithImports("config1.yml", &dst, WithReader(reader), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Empty(t, result.Warnings, "the diagnostic is disabled by default")
+}
@@ -0,0 +1,44 @@
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	TabIndentationErr = errors.New("tab character used for indentation")
+
+	// syntaxErrLineRe matches the line number of yaml.v2 syntax error
+	syntaxErrLineRe = regexp.MustCompile(`yaml: line (\d+):`)
+)
+
+// explainParseError replaces the cryptic syntax error caused by tab indentation in the raw file
+// with the error naming the file and the line. Only the line the error is reported at is inspected,
+// as tabs are valid content of block scalars. Other errors are returned as is
+func explainParseError(resource string, raw []byte, err error) error {
+	var typeErr *yaml.TypeError
+	if err == nil || errors.As(err, &typeErr) {
+		return err
+	}
+	match := syntaxErrLineRe.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	n, _ := strconv.Atoi(match[1])
+	lines := bytes.Split(raw, []byte("\n"))
+	if n < 1 || n > len(lines) {
+		return err
+	}
+	line := lines[n-1]
+	indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+	if bytes.IndexByte(indent, '\t') >= 0 && len(bytes.TrimSpace(line)) > 0 {
+		return fmt.Errorf("%s:%d: %w", resource, n, TabIndentationErr)
+	}
+
+	return err
+}
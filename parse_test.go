@@ -0,0 +1,47 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestTabIndentation(t *testing.T) {
+	files := map[string][]byte{
+		// the tab inside the block scalar is a part of the value
+		"config/app.yml":  []byte("imports:\n - {resource: x.yml}\nscript: |\n  echo\t1\n  \techo 2\nname: app"),
+		"config/x.yml":    []byte("db:\n  host: localhost\n\tport: 5432\n"),
+		"config/base.yml": []byte("imports:\n - {resource: x.yml, ignore_errors: true}\nname: base"),
+		"config/root.yml": []byte("name: root\ndb:\n\thost: localhost\n"),
+	}
+	reader := mapReader(files)
+
+	var ts struct{ Name, Script string }
+	err := ProcessFileWithImports("config/app.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, TabIndentationErr))
+	assert.EqualError(t, err, "config/app.yml -> config/x.yml:3: tab character used for indentation")
+
+	err = ProcessFileWithImports("config/root.yml", &ts, WithReader(reader))
+	assert.EqualError(t, err, "config/root.yml:3: tab character used for indentation")
+
+	err = ProcessFileWithImports("config/base.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "base", ts.Name)
+
+	files["config/x.yml"] = []byte("db:\n  host: localhost\n")
+	err = ProcessFileWithImports("config/app.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "echo\t1\n\techo 2\n", ts.Script)
+}
+
+func TestTabInBlockScalarWithSyntaxError(t *testing.T) {
+	raw := []byte("script: |\n  echo 1\n  \techo 2\nlist: [unclosed\n")
+	var v interface{}
+	yamlErr := yaml.Unmarshal(raw, &v)
+
+	err := explainParseError("app.yml", raw, yamlErr)
+	assert.False(t, errors.Is(err, TabIndentationErr), "the tab of the block scalar is not the cause")
+	assert.Equal(t, yamlErr, err)
+}
@@ -0,0 +1,52 @@
+package yaml
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// PortabilityIssue is an import which makes the config depend on the machine it's checked on
+type PortabilityIssue struct {
+	// File is the file declaring the import
+	File     string
+	Resource string
+	Reason   string
+}
+
+// CheckPortability discovers the imports tree of the config file and reports the imports with absolute paths,
+// and the imports resolved outside of the project root, which is the directory of the config file
+// The issues don't fail the check, only the errors of imports discovery do
+// ioutil.ReadFile is used if reader is nil
+func CheckPortability(configPath string, reader ReadFileFunc) ([]PortabilityIssue, error) {
+	var opts []Option
+	if reader != nil {
+		opts = append(opts, WithReader(reader))
+	}
+	o := newOptions(opts)
+	var parents []int
+	o.importParents = &parents
+	importList, err := getReverseOrderedImports(configPath, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		issues []PortabilityIssue
+		root   = filepath.Dir(configPath)
+	)
+	for i := 1; i < len(importList); i++ {
+		issue := PortabilityIssue{File: importList[parents[i]].Resource, Resource: importList[i].Resource}
+		if filepath.IsAbs(importList[i].Resource) {
+			issue.Reason = "absolute path"
+			issues = append(issues, issue)
+			continue
+		}
+		rel, err := filepath.Rel(root, filepath.Clean(importList[i].Resource))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			issue.Reason = "outside of the project root " + root
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,37 @@
+package yaml
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPortability(t *testing.T) {
+	files := map[string][]byte{
+		"project/config.yml": []byte("imports:\n - {resource: db.yml}\n - {resource: /etc/app/local.yml, ignore_errors: true}\n" +
+			" - {resource: ../shared/common.yml}\n - {resource: sub/../sub/cache.yml}"),
+		"project/db.yml":        []byte("imports:\n - {resource: ../project/limits.yml}"),
+		"project/limits.yml":    []byte("cpu: 2"),
+		"project/sub/cache.yml": []byte("size: 10"),
+		"shared/common.yml":     []byte("name: common"),
+	}
+	reader := func(filename string) ([]byte, error) {
+		if data, ok := files[filepath.Clean(filename)]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+
+	issues, err := CheckPortability("project/config.yml", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, []PortabilityIssue{
+		{File: "project/config.yml", Resource: "shared/common.yml", Reason: "outside of the project root project"},
+		{File: "project/config.yml", Resource: "/etc/app/local.yml", Reason: "absolute path"},
+	}, issues)
+
+	issues, err = CheckPortability("project/db.yml", reader)
+	assert.Nil(t, err)
+	assert.Nil(t, issues)
+}
@@ -0,0 +1,49 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+var InvalidOutputStyleErr = errors.New("output style is invalid")
+
+// OutputStyle is the formatting of the merged config returned by ProcessFileWithRaw, the zero value is the default
+// style: 2 spaces indentation, multi-line strings written as literal blocks, and the line break at the end
+type OutputStyle struct {
+	// Indent is the number of spaces nested values are indented by, at least 2, 0 keeps the default
+	Indent int
+	// QuotedStrings writes multi-line strings double-quoted instead of literal blocks
+	QuotedStrings bool
+	// NoTrailingNewline leaves out the line break at the end of the document
+	NoTrailingNewline bool
+}
+
+// ProcessFileWithRaw processes config file and all it's imports tree into dst, and returns the merged config
+// marshaled as YAML without the imports, so the effective config can be logged or saved
+func ProcessFileWithRaw(configPath string, dst interface{}, opts ...Option) ([]byte, error) {
+	if err := checkDst(dst); err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
+	if o.outputStyle != nil && o.outputStyle.indent < 2 {
+		return nil, fmt.Errorf("indent %d is less than 2: %w", o.outputStyle.indent, InvalidOutputStyleErr)
+	}
+	merged, err := processFile(configPath, dst, o)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := yaml.Marshal(merged)
+	if err != nil || o.outputStyle == nil {
+		return raw, err
+	}
+	// yaml.v2 formatting can't be changed, the marshaled config is encoded again with the style
+	root, err := decodeNode(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.outputStyle.encode(root), nil
+}
@@ -0,0 +1,79 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestProcessFileWithRaw(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: db.yml}\nversion: '1.10'\ndb:\n  host: primary\nscript: |\n  echo 1\n  echo 2\n"),
+		"db.yml":     []byte("inherit_imports: true\ndb:\n  host: localhost\n  port: 5432\n  timeout: 5s\ntags: [a, 'on']\n"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Version string
+		Script  string
+		DB      struct {
+			Host    string
+			Port    int
+			Timeout time.Duration
+		}
+		Tags []string
+	}
+
+	var ts testStruct
+	raw, err := ProcessFileWithRaw("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(raw), "imports")
+
+	var decoded testStruct
+	assert.Nil(t, yaml.UnmarshalStrict(raw, &decoded))
+	assert.Equal(t, ts, decoded)
+	assert.Equal(t, "1.10", decoded.Version)
+	assert.Equal(t, []string{"a", "on"}, decoded.Tags)
+}
+
+func TestWithOutputStyle(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: db.yml}\nname: app\nscript: |\n  echo 1\n  echo 2\n"),
+		"db.yml":     []byte("db:\n  hosts:\n    - {host: primary, port: 5432}\n    - replica\n"),
+	}
+	reader := mapReader(files)
+
+	var ts struct{ Name string }
+	raw, err := ProcessFileWithRaw("config.yml", &ts, WithReader(reader), WithOutputStyle(OutputStyle{Indent: 4}))
+	assert.Nil(t, err)
+	assert.Equal(t, "db:\n"+
+		"    hosts:\n"+
+		"        -   host: primary\n"+
+		"            port: 5432\n"+
+		"        - replica\n"+
+		"name: app\n"+
+		"script: |\n"+
+		"    echo 1\n"+
+		"    echo 2\n", string(raw))
+
+	raw, err = ProcessFileWithRaw("config.yml", &ts, WithReader(reader), WithOutputStyle(OutputStyle{QuotedStrings: true, NoTrailingNewline: true}))
+	assert.Nil(t, err)
+	assert.Equal(t, "db:\n"+
+		"  hosts:\n"+
+		"    - host: primary\n"+
+		"      port: 5432\n"+
+		"    - replica\n"+
+		"name: app\n"+
+		`script: "echo 1\necho 2\n"`, string(raw))
+
+	var decoded map[string]interface{}
+	assert.Nil(t, yaml.Unmarshal(raw, &decoded))
+	assert.Equal(t, "echo 1\necho 2\n", decoded["script"])
+
+	for _, indent := range []int{-1, 1} {
+		_, err = ProcessFileWithRaw("config.yml", &ts, WithReader(reader), WithOutputStyle(OutputStyle{Indent: indent}))
+		assert.True(t, errors.Is(err, InvalidOutputStyleErr))
+	}
+}
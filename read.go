@@ -0,0 +1,193 @@
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ReadTimeoutErr = errors.New("config file read timed out")
+
+// read reads the resource of the import decoded to UTF-8, unless it's already read
+func (o *options) read(ci configImport) ([]byte, error) {
+	if o.ctx != nil && o.ctx.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", ci.Resource, o.ctx.Err())
+	}
+	if ci.raw != nil {
+		return ci.raw, nil
+	}
+	data, err := o.readResource(ci)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeText(ci.Resource, data, o.encoding)
+}
+
+// readResult is the content of the resource read, or the error of the read
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// readAll reads the resources of the imports with o.parallelReads workers concurrently, the results are
+// in the order of the imports whatever order the reads complete in
+func (o *options) readAll(imports []configImport) []readResult {
+	var (
+		results = make([]readResult, len(imports))
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < o.parallelReads && w < len(imports); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				data, err := o.read(imports[j])
+				results[j] = readResult{data, err}
+			}
+		}()
+	}
+	for j := range imports {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// prefetch reads concurrently the files to be loaded next, starting from the i-th import: up to o.parallelReads
+// of the ones which are not cached, so the files are still loaded in order, but without waiting for every read
+func (o *options) prefetch(importList []configImport, i int) map[int]readResult {
+	var (
+		indexes []int
+		imports []configImport
+	)
+	for j := i; j >= 0 && len(imports) < o.parallelReads; j-- {
+		if !importList[j].corrupted && importList[j].raw == nil {
+			indexes, imports = append(indexes, j), append(imports, importList[j])
+		}
+	}
+	prefetched := make(map[int]readResult, len(imports))
+	for j, r := range o.readAll(imports) {
+		prefetched[indexes[j]] = r
+	}
+
+	return prefetched
+}
+
+// readResource reads the resource of the import with the reader, honoring the read timeout and the context
+func (o *options) readResource(ci configImport) ([]byte, error) {
+	timeout := o.readTimeout
+	if ci.timeout > 0 {
+		timeout = ci.timeout
+	}
+	var cancelled <-chan struct{}
+	if o.ctx != nil {
+		cancelled = o.ctx.Done()
+	}
+	if timeout <= 0 && cancelled == nil {
+		return o.callReader(ci.Resource)
+	}
+
+	// the reader can't be interrupted, so it's left to finish in background after the timeout or the cancellation
+	done := make(chan readResult, 1)
+	go func() {
+		data, err := o.callReader(ci.Resource)
+		done <- readResult{data, err}
+	}()
+
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+	select {
+	case result := <-done:
+		return result.data, result.err
+	case <-timedOut:
+		return nil, fmt.Errorf("%s: %w after %s", ci.Resource, ReadTimeoutErr, timeout)
+	case <-cancelled:
+		return nil, fmt.Errorf("%s: %w", ci.Resource, o.ctx.Err())
+	}
+}
+
+// rootImports returns the imports tree of the base config with the base config content read in advance
+// A config without imports is the common case, it's not parsed to discover the imports: the file is read once
+// and decoded in a single pass
+func (o *options) rootImports(configPath string) ([]configImport, error) {
+	root := configImport{Resource: configPath}
+	var (
+		raw []byte
+		err error
+	)
+	if o.rootRaw != nil {
+		raw, err = decodeText(configPath, o.rootRaw, o.encoding)
+	} else {
+		raw, err = o.read(root)
+	}
+	if err != nil {
+		if o.skipMissingRoot(err) {
+			if o.result != nil {
+				o.result.Skipped = append(o.result.Skipped, SkippedImport{Resource: configPath, Reason: "base config is missing"})
+			}
+			return nil, nil
+		}
+		return nil, err
+	}
+	root.raw = raw
+	if o.importsDisabled {
+		return []configImport{root}, nil
+	}
+
+	// rendering and substitution change the content, so the imports can appear only after the discovery
+	if bytes.Contains(raw, []byte(o.importsKey)) || o.templateData != nil || o.envSubstitution || o.importParents != nil || o.importEdges != nil {
+		return discoverImports(root, o)
+	}
+
+	return []configImport{root}, nil
+}
+
+// skipMissingRoot checks if processing goes on without the base config which can't be read with the error
+// with WithOptionalRoot, timeouts and cancellations are never skipped
+func (o *options) skipMissingRoot(err error) bool {
+	return o.optionalRoot && o.isNotFound(err)
+}
+
+// isNotFound checks if the read failed because the file doesn't exist. The errors of custom readers don't tell it,
+// so any of them but timeouts and cancellations counts
+func (o *options) isNotFound(err error) bool {
+	if errors.Is(err, ReadTimeoutErr) || isCancelled(err) {
+		return false
+	}
+
+	return o.customReader || errors.Is(err, os.ErrNotExist)
+}
+
+// readAlternateExtension reads the resource of the import which is not found with the alternate extension:
+// .yaml instead of .yml, and vice versa. The resource read is returned, ok is false if it can't be read either
+func (o *options) readAlternateExtension(ci configImport, err error) (data []byte, resource string, ok bool) {
+	if !o.extensionFallback || !o.isNotFound(err) {
+		return nil, "", false
+	}
+	switch {
+	case strings.HasSuffix(ci.Resource, ".yml"):
+		ci.Resource = strings.TrimSuffix(ci.Resource, ".yml") + ".yaml"
+	case strings.HasSuffix(ci.Resource, ".yaml"):
+		ci.Resource = strings.TrimSuffix(ci.Resource, ".yaml") + ".yml"
+	default:
+		return nil, "", false
+	}
+	data, err = o.read(ci)
+	if err != nil {
+		return nil, "", false
+	}
+
+	return data, ci.Resource, true
+}
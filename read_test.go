@@ -0,0 +1,307 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTimeout(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: remote.yml, timeout: 1s}\na: config1"),
+		"config2.yml": []byte("imports:\n - {resource: remote.yml}\na: config2"),
+		"config3.yml": []byte("imports:\n - {resource: remote.yml, timeout: soon}"),
+		"remote.yml":  []byte("b: remote"),
+	}
+	reader := func(filename string) ([]byte, error) {
+		if filename == "remote.yml" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+	type testStruct struct{ A, B string }
+
+	var ts testStruct
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithReadTimeout(10*time.Millisecond))
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{A: "config1", B: "remote"}, ts)
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("config2.yml", &ts, WithReader(reader), WithReadTimeout(10*time.Millisecond))
+	assert.True(t, errors.Is(err, ReadTimeoutErr))
+	assert.EqualError(t, err, "remote.yml: config file read timed out after 10ms")
+
+	err = ProcessFileWithImports("config3.yml", &ts, WithReader(reader))
+	assert.EqualError(t, err, "config3.yml: invalid timeout of remote.yml: time: invalid duration \"soon\"")
+}
+
+func TestWithMaxCacheBytes(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: db.yml}\n - {resource: large.yml}\nname: app"),
+		"db.yml":     []byte("db: {host: localhost}"),
+		"large.yml":  []byte("description: " + strings.Repeat("x", 1000)),
+	}
+	reads := make(map[string]int)
+	reader := func(filename string) ([]byte, error) {
+		reads[filename]++
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+	type testStruct struct {
+		Name        string
+		Description string
+		DB          struct{ Host string }
+	}
+
+	var cached testStruct
+	err := ProcessFileWithImports("config.yml", &cached, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"config.yml": 1, "db.yml": 1, "large.yml": 1}, reads)
+
+	reads = make(map[string]int)
+	var limited testStruct
+	err = ProcessFileWithImports("config.yml", &limited, WithReader(reader), WithMaxCacheBytes(200))
+	assert.Nil(t, err)
+	assert.Equal(t, cached, limited)
+	assert.Equal(t, map[string]int{"config.yml": 1, "db.yml": 1, "large.yml": 2}, reads, "large file doesn't fit the cache")
+
+	reads = make(map[string]int)
+	limited = testStruct{}
+	err = ProcessFileWithImports("config.yml", &limited, WithReader(reader), WithMaxCacheBytes(1))
+	assert.Nil(t, err)
+	assert.Equal(t, cached, limited)
+	assert.Equal(t, map[string]int{"config.yml": 1, "db.yml": 2, "large.yml": 2}, reads)
+}
+
+func TestWithParallelReads(t *testing.T) {
+	files := map[string][]byte{}
+	var imports []string
+	for i := 0; i < 6; i++ {
+		var leaves []string
+		for j := 0; j < 4; j++ {
+			leaf := fmt.Sprintf("leaf%d_%d.yml", i, j)
+			leaves = append(leaves, " - {resource: "+leaf+"}")
+			files[leaf] = []byte(fmt.Sprintf("level: %s\nleaf: %s\nleaves:\n  l%d: %d", leaf, leaf, j, i))
+		}
+		name := fmt.Sprintf("branch%d.yml", i)
+		imports = append(imports, " - {resource: "+name+"}")
+		files[name] = []byte(fmt.Sprintf("imports:\n%s\nlevel: %s\nbranches: [%d]", strings.Join(leaves, "\n"), name, i))
+	}
+	files["config.yml"] = []byte("imports:\n" + strings.Join(imports, "\n") + "\nname: app")
+	var reads int32
+	reader := func(filename string) ([]byte, error) {
+		// the reads complete in the order which differs from the order of the tree
+		n := atomic.AddInt32(&reads, 1)
+		time.Sleep(time.Duration(n*7%5) * time.Millisecond)
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+
+	expected, err := ProcessFileWithRaw("config.yml", &map[string]interface{}{}, WithReader(reader))
+	assert.Nil(t, err)
+	for i := 0; i < 20; i++ {
+		result := Result{}
+		raw, err := ProcessFileWithRaw("config.yml", &map[string]interface{}{}, WithReader(reader), WithParallelReads(4), WithResult(&result))
+		assert.Nil(t, err)
+		assert.Equal(t, string(expected), string(raw))
+		assert.Len(t, result.Loaded, len(files))
+	}
+}
+
+// slowTree returns the files of the config with n imports, the reader which sleeps on every read,
+// and the function returning the maximum number of the reads in flight at once so far
+func slowTree(n int, delay time.Duration) (map[string][]byte, ReadFileFunc, func() int32) {
+	files := map[string][]byte{}
+	var imports []string
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("remote%d.yml", i)
+		imports = append(imports, " - {resource: "+name+"}")
+		files[name] = []byte(fmt.Sprintf("last: %s\nremotes:\n  r%d: %d", name, i, i))
+	}
+	files["config.yml"] = []byte("imports:\n" + strings.Join(imports, "\n") + "\nname: app")
+	var inFlight, maxInFlight int32
+	reader := func(filename string) ([]byte, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for max := atomic.LoadInt32(&maxInFlight); n > max && !atomic.CompareAndSwapInt32(&maxInFlight, max, n); {
+			max = atomic.LoadInt32(&maxInFlight)
+		}
+		time.Sleep(delay)
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+
+	return files, reader, func() int32 {
+		return atomic.SwapInt32(&maxInFlight, 0)
+	}
+}
+
+func TestWithParallelReadsConcurrent(t *testing.T) {
+	files, reader, maxInFlight := slowTree(12, 20*time.Millisecond)
+
+	expectedResult := Result{}
+	expected, err := ProcessFileWithRaw("config.yml", &map[string]interface{}{}, WithReader(reader), WithResult(&expectedResult))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), maxInFlight(), "reads are sequential by default")
+
+	for _, opts := range [][]Option{
+		{WithParallelReads(6)},
+		// the files which are not cached are read again concurrently to be loaded
+		{WithParallelReads(6), WithMaxCacheBytes(1)},
+	} {
+		result := Result{}
+		raw, err := ProcessFileWithRaw("config.yml", &map[string]interface{}{}, append(opts, WithReader(reader), WithResult(&result))...)
+		assert.Nil(t, err)
+		assert.Equal(t, string(expected), string(raw))
+		assert.Len(t, result.Loaded, len(files))
+		assert.Equal(t, expectedResult.Loaded, result.Loaded, "the files are loaded in the order of the tree")
+		inFlight := maxInFlight()
+		assert.Greater(t, inFlight, int32(1), "reads are concurrent")
+		assert.LessOrEqual(t, inFlight, int32(6), "reads are limited by the number of workers")
+	}
+}
+
+func BenchmarkWithParallelReads(b *testing.B) {
+	_, reader, _ := slowTree(12, time.Millisecond)
+
+	for _, workers := range []int{1, 4, 12} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				dst := map[string]interface{}{}
+				if err := ProcessFileWithImports("config.yml", &dst, WithReader(reader), WithParallelReads(workers), WithMaxCacheBytes(1)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestWithOptionalRoot(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "broken.yml"), []byte("name: [unclosed"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "ignored.yml"), []byte("imports:\n - {resource: missing.yml, ignore_errors: true}\nname: ignored"), 0644))
+	type testStruct struct{ Name string }
+
+	ts := testStruct{Name: "default"}
+	result := Result{}
+	err := ProcessFileWithImports(filepath.Join(dir, "local.yml"), &ts, WithOptionalRoot(), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, "default", ts.Name, "dst is left as is")
+	assert.Equal(t, []SkippedImport{{Resource: filepath.Join(dir, "local.yml"), Reason: "base config is missing"}}, result.Skipped)
+
+	err = ProcessFileWithImports(filepath.Join(dir, "local.yml"), &ts)
+	assert.True(t, errors.Is(err, os.ErrNotExist), "the base config is required by default")
+
+	err = ProcessFileWithImports(filepath.Join(dir, "broken.yml"), &ts, WithOptionalRoot())
+	assert.EqualError(t, err, filepath.Join(dir, "broken.yml")+": yaml: line 1: did not find expected ',' or ']'")
+	err = ProcessFileWithImports(dir, &ts, WithOptionalRoot())
+	assert.True(t, errors.Is(err, NotRegularFileErr))
+
+	err = ProcessFileWithImports(filepath.Join(dir, "ignored.yml"), &ts, WithOptionalRoot())
+	assert.Nil(t, err)
+	assert.Equal(t, "ignored", ts.Name)
+
+	// custom readers don't tell why the file can't be read
+	reader := func(filename string) ([]byte, error) {
+		return nil, errors.New("no such file")
+	}
+	err = ProcessFileWithImports("local.yml", &ts, WithReader(reader), WithOptionalRoot())
+	assert.Nil(t, err)
+	err = ProcessFileWithImports("local.yml", &ts, WithReader(func(filename string) ([]byte, error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	}), WithOptionalRoot(), WithReadTimeout(10*time.Millisecond))
+	assert.True(t, errors.Is(err, ReadTimeoutErr))
+}
+
+func TestWithExtensionFallback(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yml":     "imports:\n - {resource: db.yml}\n - {resource: " + filepath.Join(dir, "cache.yaml") + "}\nname: app",
+		"db.yaml":        "db: localhost",
+		"cache.yml":      "cache: redis",
+		"broken.yml":     "imports:\n - {resource: malformed.yml}",
+		"malformed.yml":  "db: [unclosed",
+		"malformed.yaml": "db: localhost",
+		"ignored.yml":    "imports:\n - {resource: missing.yml, ignore_errors: true}\n - {resource: cache.yaml}\nname: ignored",
+	}
+	for name, data := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644))
+	}
+	type testStruct struct{ Name, DB, Cache string }
+
+	var ts testStruct
+	result := Result{}
+	err := ProcessFileWithImports(filepath.Join(dir, "config.yml"), &ts, WithExtensionFallback(), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{Name: "app", DB: "localhost", Cache: "redis"}, ts)
+	assert.Equal(t, []string{filepath.Join(dir, "db.yaml"), filepath.Join(dir, "cache.yml"), filepath.Join(dir, "config.yml")}, result.Loaded,
+		"the files found are loaded")
+
+	err = ProcessFileWithImports(filepath.Join(dir, "config.yml"), &ts)
+	assert.True(t, errors.Is(err, os.ErrNotExist), "the extension must match by default")
+
+	err = ProcessFileWithImports(filepath.Join(dir, "broken.yml"), &ts, WithExtensionFallback())
+	assert.True(t, errors.Is(err, ImportParseErr), "malformed files are not retried")
+
+	ts = testStruct{}
+	err = ProcessFileWithImports(filepath.Join(dir, "ignored.yml"), &ts, WithExtensionFallback())
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{Name: "ignored", Cache: "redis"}, ts)
+
+	reader := func(filename string) ([]byte, error) {
+		if filename == "config.yaml" {
+			return []byte("imports:\n - {resource: db.yml}"), nil
+		}
+		if filename == "db.yaml" {
+			return []byte("db: custom"), nil
+		}
+		return nil, errors.New("no such file")
+	}
+	ts = testStruct{}
+	err = ProcessFileWithImports("config.yaml", &ts, WithReader(reader), WithExtensionFallback())
+	assert.Nil(t, err)
+	assert.Equal(t, "custom", ts.DB)
+}
+
+func TestWithExtensionFallbackDirectImport(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: cache.yml}\n - {resource: db.yml}\nname: app"),
+		"cache.yml":  []byte("imports:\n - {resource: db.yaml}\n - {resource: db.yml}\ncache: redis"),
+		"db.yaml":    []byte("db: localhost"),
+	}
+	reads := make(map[string]int)
+	reader := func(filename string) ([]byte, error) {
+		reads[filename]++
+		return mapReader(files)(filename)
+	}
+	type testStruct struct{ Name, DB, Cache string }
+
+	var (
+		ts     testStruct
+		result Result
+	)
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithExtensionFallback(), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{Name: "app", DB: "localhost", Cache: "redis"}, ts)
+	assert.Equal(t, []string{"cache.yml", "db.yaml", "config.yml"}, result.Loaded)
+	assert.Equal(t, 1, reads["db.yaml"], "the direct import of the file found with the alternate extension is not loaded again")
+	assert.Equal(t, 1, reads["db.yml"])
+}
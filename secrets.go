@@ -0,0 +1,30 @@
+package yaml
+
+import "fmt"
+
+// SecretTag marks the values resolved with SecretResolver, like `password: !vault secret/db#password`
+const SecretTag = "!vault"
+
+// SecretResolver resolves the references of values tagged with SecretTag,
+// so secrets are not stored in config files, but still go through imports and merging
+type SecretResolver interface {
+	ResolveSecret(ref string) (string, error)
+}
+
+// resolveSecrets replaces the tagged values with the secrets from the resolver
+func (o *options) resolveSecrets(root *node, resource string) (bool, error) {
+	resolved := false
+	err := root.walk(func(n *node) error {
+		if n.kind != scalarNode || n.tag != SecretTag {
+			return nil
+		}
+		secret, err := o.secretResolver.ResolveSecret(n.text)
+		if err != nil {
+			return fmt.Errorf("%s: line %d: secret %s: %w", resource, n.line, n.text, err)
+		}
+		n.tag, n.text, resolved = strTag, secret, true
+		return nil
+	})
+
+	return resolved, err
+}
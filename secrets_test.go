@@ -0,0 +1,79 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSecretResolver map[string]string
+
+func (r fakeSecretResolver) ResolveSecret(ref string) (string, error) {
+	if secret, ok := r[ref]; ok {
+		return secret, nil
+	}
+	return "", errors.New("secret not found")
+}
+
+func TestWithSecretResolver(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: db.yml}\ndb:\n  user: app"),
+		"db.yml":      []byte("db:\n  user: root\n  password: !vault secret/db#password\n  port: 5432"),
+		"missing.yml": []byte("token: !vault secret/api#token"),
+	}
+	reader := mapReader(files)
+	resolver := fakeSecretResolver{"secret/db#password": "s3cr3t: \"value\""}
+
+	var ts struct {
+		DB struct {
+			User     string
+			Password string
+			Port     int
+		}
+		Token string
+	}
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithSecretResolver(resolver))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.DB.User)
+	assert.Equal(t, "s3cr3t: \"value\"", ts.DB.Password)
+	assert.Equal(t, 5432, ts.DB.Port)
+
+	err = ProcessFileWithImports("missing.yml", &ts, WithReader(reader), WithSecretResolver(resolver))
+	assert.EqualError(t, err, "missing.yml: line 1: secret secret/api#token: secret not found")
+}
+
+func TestSecretErrorsSkipped(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: token.yml, ignore_errors: true}\n - {resource: db.yml}\nname: app"),
+		"token.yml":  []byte("token: !vault secret/api#token"),
+		"db.yml":     []byte("db:\n  password: !vault secret/db#password"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name  string
+		Token string
+		DB    struct{ Password string }
+	}
+
+	var (
+		ts      testStruct
+		result  Result
+		ignored []string
+	)
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithResult(&result),
+		WithSecretResolver(fakeSecretResolver{"secret/db#password": "s3cr3t"}),
+		WithOnIgnoredError(func(resource string, err error) {
+			ignored = append(ignored, resource+": "+err.Error())
+		}))
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{Name: "app", DB: struct{ Password string }{"s3cr3t"}}, ts)
+	assert.Equal(t, []string{"db.yml", "config.yml"}, result.Loaded)
+	assert.Equal(t, []string{"token.yml: token.yml: line 1: secret secret/api#token: secret not found"}, ignored)
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithAggregateErrors(),
+		WithSecretResolver(fakeSecretResolver{}))
+	assert.EqualError(t, err, "1 imports failed: db.yml: line 2: secret secret/db#password: secret not found")
+	assert.Equal(t, "app", ts.Name)
+}
@@ -0,0 +1,33 @@
+package yaml
+
+import (
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+// promoteSection merges the active section to the root of dst and the merged tree, the sections mapping is removed
+// from both of them
+func (o *options) promoteSection(dst interface{}, merged map[interface{}]interface{}) error {
+	if o.sectionsKey == "" {
+		return nil
+	}
+	sections, _ := merged[o.sectionsKey].(map[interface{}]interface{})
+	deleteTreePath(merged, []interface{}{o.sectionsKey})
+	deleteValuePath(reflect.ValueOf(dst), []interface{}{o.sectionsKey})
+
+	section, ok := sections[o.activeSection].(map[interface{}]interface{})
+	if !ok {
+		return nil
+	}
+	raw, err := yaml.Marshal(section)
+	if err != nil {
+		return err
+	}
+	if err := decodeDocument(raw, dst, false, o.directives()); err != nil {
+		return err
+	}
+	mergeTree(merged, section)
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithActiveSection(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: local.yml}\n" +
+			"log_level: info\n" +
+			"db: {host: localhost, port: 5432}\n" +
+			"environments:\n" +
+			"  prod:\n" +
+			"    log_level: warn\n" +
+			"    db: {host: db.prod}\n" +
+			"  dev:\n" +
+			"    log_level: debug\n"),
+		// the root values of the importing file are overridden by the section too
+		"local.yml": []byte("workers: 2\nenvironments:\n  prod: {workers: 8}"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		LogLevel string `yaml:"log_level"`
+		Workers  int
+		DB       struct {
+			Host string
+			Port int
+		}
+		Environments map[string]interface{}
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithActiveSection("environments", "prod"))
+	assert.Nil(t, err)
+	assert.Equal(t, "warn", ts.LogLevel)
+	assert.Equal(t, 8, ts.Workers)
+	assert.Equal(t, "db.prod", ts.DB.Host)
+	assert.Equal(t, 5432, ts.DB.Port, "nested mappings are merged")
+	assert.Nil(t, ts.Environments)
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithActiveSection("environments", "stage"))
+	assert.Nil(t, err)
+	assert.Equal(t, "info", ts.LogLevel)
+	assert.Equal(t, 2, ts.Workers)
+	assert.Nil(t, ts.Environments)
+}
@@ -0,0 +1,20 @@
+module github.com/lispad/yaml/sftpreader
+
+go 1.20
+
+require (
+	github.com/lispad/yaml v0.0.0
+	github.com/pkg/sftp v1.13.6
+	github.com/stretchr/testify v1.12.1
+	golang.org/x/crypto v0.17.0
+)
+
+require (
+	github.com/kr/fs v0.1.0 // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/lispad/yaml => ../
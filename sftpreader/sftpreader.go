@@ -0,0 +1,131 @@
+// Package sftpreader implements yaml.ReadFileFunc for config files stored on a remote host
+// and addressed as sftp://host/path resources.
+//
+// It's a separate module to keep golang.org/x/crypto out of github.com/lispad/yaml dependencies.
+//
+// Relative imports of a remote config are resolved against the remote directory,
+// so sftp://host/etc/app/config.yml importing base.yml reads sftp://host/etc/app/base.yml.
+// Connection and path failures are returned as read errors, so imports with ignore_errors are skipped as usual.
+package sftpreader
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"sync"
+
+	"github.com/lispad/yaml"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Scheme is the URL scheme of resources served by SFTPReader
+const Scheme = "sftp"
+
+var NotSFTPResourceErr = errors.New("resource is not an sftp:// URL")
+
+type (
+	// Dialer opens SFTP session to the host, as it's written in the resource URL
+	Dialer func(host string) (*sftp.Client, error)
+
+	// SFTPReader reads sftp://host/path resources, keeping one SFTP session per host
+	// Resources with other schemes or plain paths are read with Fallback, if it's set
+	SFTPReader struct {
+		Fallback yaml.ReadFileFunc
+
+		dial    Dialer
+		mu      sync.Mutex
+		clients map[string]*sftp.Client
+	}
+)
+
+// New returns SFTPReader connecting to hosts over SSH with the given client config
+// Hosts without port are dialed at port 22
+func New(config *ssh.ClientConfig) *SFTPReader {
+	return NewWithDialer(func(host string) (*sftp.Client, error) {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, "22")
+		}
+		conn, err := ssh.Dial("tcp", host, config)
+		if err != nil {
+			return nil, err
+		}
+		client, err := sftp.NewClient(conn)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		go func() {
+			// the SSH connection isn't owned by the SFTP client, close it together with the session
+			client.Wait()
+			conn.Close()
+		}()
+
+		return client, nil
+	})
+}
+
+// NewWithDialer returns SFTPReader opening sessions with custom dialer
+func NewWithDialer(dial Dialer) *SFTPReader {
+	return &SFTPReader{
+		dial:    dial,
+		clients: make(map[string]*sftp.Client),
+	}
+}
+
+// ReadFile reads the resource, it has yaml.ReadFileFunc signature
+func (r *SFTPReader) ReadFile(resource string) ([]byte, error) {
+	u, err := url.Parse(resource)
+	if err != nil || u.Scheme != Scheme {
+		if r.Fallback != nil {
+			return r.Fallback(resource)
+		}
+		return nil, fmt.Errorf("%s: %w", resource, NotSFTPResourceErr)
+	}
+
+	client, err := r.client(u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", resource, err)
+	}
+	file, err := client.Open(u.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", resource, err)
+	}
+	defer file.Close()
+
+	return ioutil.ReadAll(file)
+}
+
+// Close closes all opened SFTP sessions
+func (r *SFTPReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var firstErr error
+	for host, client := range r.clients {
+		if err := client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(r.clients, host)
+	}
+
+	return firstErr
+}
+
+func (r *SFTPReader) client(host string) (*sftp.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if client, ok := r.clients[host]; ok {
+		return client, nil
+	}
+	client, err := r.dial(host)
+	if err != nil {
+		return nil, err
+	}
+	r.clients[host] = client
+
+	return client, nil
+}
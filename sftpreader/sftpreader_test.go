@@ -0,0 +1,84 @@
+package sftpreader
+
+import (
+	"errors"
+	"net"
+	"path"
+	"testing"
+
+	"github.com/lispad/yaml"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockServer starts in-memory SFTP server with the files and returns the client connected to it
+func newMockServer(t *testing.T, files map[string]string) *sftp.Client {
+	serverConn, clientConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, sftp.InMemHandler())
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	for name, content := range files {
+		if err := client.MkdirAll(path.Dir(name)); err != nil {
+			t.Fatal(err)
+		}
+		f, err := client.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+
+	return client
+}
+
+func TestSFTPReader(t *testing.T) {
+	var unreachableErr = errors.New("host is unreachable")
+	client := newMockServer(t, map[string]string{
+		"/etc/app/config.yml": "imports:\n" +
+			" - {resource: base.yml}\n" +
+			" - {resource: missing.yml, ignore_errors: true}\n" +
+			" - {resource: 'sftp://down/etc/app/local.yml', ignore_errors: true}\n" +
+			"a: from config",
+		"/etc/app/base.yml": "a: from base\nb: from base",
+	})
+	reader := NewWithDialer(func(host string) (*sftp.Client, error) {
+		if host == "mgmt" {
+			return client, nil
+		}
+		return nil, unreachableErr
+	})
+
+	var ts struct {
+		A string
+		B string
+	}
+	err := yaml.ProcessFileWithImports("sftp://mgmt/etc/app/config.yml", &ts, yaml.WithReader(reader.ReadFile))
+	assert.Nil(t, err)
+	assert.Equal(t, "from config", ts.A)
+	assert.Equal(t, "from base", ts.B)
+
+	_, err = reader.ReadFile("sftp://down/etc/app/config.yml")
+	assert.True(t, errors.Is(err, unreachableErr))
+
+	_, err = reader.ReadFile("/etc/app/config.yml")
+	assert.True(t, errors.Is(err, NotSFTPResourceErr))
+
+	reader.Fallback = func(filename string) ([]byte, error) {
+		return []byte("a: local"), nil
+	}
+	data, err := reader.ReadFile("/etc/app/config.yml")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("a: local"), data)
+}
@@ -0,0 +1,41 @@
+package yaml
+
+import "reflect"
+
+// appendSlices appends the slices of the document to the slices of previous, the copy of struct dst made before
+// the document was decoded into it: yaml.Unmarshal replaces the slices, so the document is decoded again
+// into a new struct to find the slices it sets. Other kinds of dst are left as is
+func appendSlices(dst, previous interface{}, document []byte, directives []string) error {
+	v := reflect.ValueOf(dst).Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	layer := reflect.New(v.Type())
+	if err := decodeDocument(document, layer.Interface(), false, directives); err != nil {
+		return err
+	}
+	appendSliceValues(v, reflect.ValueOf(previous).Elem(), layer.Elem())
+
+	return nil
+}
+
+// appendSliceValues sets the slices of dst, which are set by the layer, to the slices of previous with the values
+// of the layer appended, the nested structs and the pointers to structs are walked recursively
+func appendSliceValues(dst, previous, layer reflect.Value) {
+	switch dst.Kind() {
+	case reflect.Struct:
+		for i := 0; i < dst.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				appendSliceValues(dst.Field(i), previous.Field(i), layer.Field(i))
+			}
+		}
+	case reflect.Ptr:
+		if !dst.IsNil() && !previous.IsNil() && !layer.IsNil() {
+			appendSliceValues(dst.Elem(), previous.Elem(), layer.Elem())
+		}
+	case reflect.Slice:
+		if !layer.IsNil() {
+			dst.Set(reflect.AppendSlice(previous, layer))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAppendSlices(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n" +
+			" - {resource: base.yml}\n" +
+			" - {resource: features/auth.yml}\n" +
+			" - {resource: features/metrics.yml}\n" +
+			"middleware: [recover]\n" +
+			"server:\n  hosts: [app.example.com]"),
+		"base.yml":             []byte("middleware: [logging]\nserver:\n  port: 8080\n  hosts: [localhost]\n  tls:\n    ciphers: [a]"),
+		"features/auth.yml":    []byte("middleware: [auth]\nserver:\n  tls:\n    ciphers: [b]"),
+		"features/metrics.yml": []byte("middleware: [metrics]\nname: app"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name       string
+		Middleware []string
+		Server     struct {
+			Port  int
+			Hosts []string
+			TLS   *struct{ Ciphers []string }
+		}
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"recover"}, ts.Middleware, "slices are replaced by default")
+	assert.Equal(t, []string{"b"}, ts.Server.TLS.Ciphers)
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithAppendSlices())
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, []string{"logging", "auth", "metrics", "recover"}, ts.Middleware)
+	assert.Equal(t, 8080, ts.Server.Port)
+	assert.Equal(t, []string{"localhost", "app.example.com"}, ts.Server.Hosts)
+	assert.Equal(t, []string{"a", "b"}, ts.Server.TLS.Ciphers)
+
+	generic := map[string]interface{}{}
+	err = ProcessFileWithImports("config.yml", &generic, WithReader(reader), WithAppendSlices())
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"recover"}, generic["middleware"], "map dst is not changed")
+}
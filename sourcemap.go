@@ -0,0 +1,162 @@
+package yaml
+
+import (
+	"strings"
+)
+
+// SourceLoc is the position of a key in a config file, lines and columns are 1-based
+// The keys merged with << are located in the mapping they are merged from
+type SourceLoc struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// SourceMap processes config file and all it's imports tree, and returns the location which set the final value
+// of every leaf key of the merged config, keys are dotted paths like db.host, with dots of the keys escaped: my\.key
+// The files go through the same rendering, env substitution, document selection and transforms as processed ones,
+// the locations refer to the rendered text. JSON files are located in their source, as it's a valid YAML
+// ioutil.ReadFile is used if reader is nil, the options are the ones the config is processed with
+func SourceMap(configPath string, reader ReadFileFunc, opts ...Option) (map[string]SourceLoc, error) {
+	if reader != nil {
+		opts = append(opts[:len(opts):len(opts)], WithReader(reader))
+	}
+	o := newOptions(opts)
+	if err := checkRegularFile(configPath, o); err != nil && !o.skipMissingRoot(err) {
+		return nil, err
+	}
+	importList, err := o.rootImports(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	locs := make(map[string]SourceLoc)
+	for i := len(importList) - 1; i >= 0; i-- {
+		if importList[i].corrupted {
+			continue
+		}
+		if err := o.locateFile(i, importList[i], locs); err != nil {
+			if importList[i].IgnoreErrors {
+				continue
+			}
+			return nil, err
+		}
+	}
+	o.promoteSectionLocs(locs)
+
+	return locs, nil
+}
+
+// locateFile records the locations of the keys set by the imported file, over the ones of the files loaded before
+// The documents of the file are the ones loaded with the options, the keys are located at their nodes
+func (o *options) locateFile(i int, ci configImport, locs map[string]SourceLoc) error {
+	raw, err := o.read(ci)
+	if err != nil {
+		return err
+	}
+	_, documents, err := o.fileDocuments(i, ci, raw, new(interface{}))
+	if err != nil {
+		return err
+	}
+
+	for _, document := range documents {
+		for _, path := range document.deleted {
+			deleteLocs(locs, keyPathOf(path))
+		}
+		root := document.root
+		if root == nil || root.kind != mappingNode {
+			continue
+		}
+		for _, key := range o.directives() {
+			root.removeKey(key)
+		}
+		locate(root, "", ci.Resource, locs)
+	}
+
+	return nil
+}
+
+// promoteSectionLocs moves the locations of the active section to the root, over the ones set there,
+// as the section is merged to the root of dst. The locations of the sections mapping are removed
+func (o *options) promoteSectionLocs(locs map[string]SourceLoc) {
+	if o.sectionsKey == "" {
+		return
+	}
+	prefix := joinKeyPath(joinKeyPath("", o.sectionsKey), o.activeSection) + "."
+	promoted := make(map[string]SourceLoc)
+	for keyPath, loc := range locs {
+		if strings.HasPrefix(keyPath, prefix) {
+			promoted[strings.TrimPrefix(keyPath, prefix)] = loc
+		}
+	}
+	deleteLocs(locs, joinKeyPath("", o.sectionsKey))
+
+	for keyPath := range promoted {
+		var path []interface{}
+		for _, key := range SplitKeyPath(keyPath) {
+			path = append(path, key)
+			// the keys of the path are mappings now, so the values they had before are replaced
+			delete(locs, keyPathOf(path))
+		}
+		deleteLocs(locs, keyPath)
+	}
+	for keyPath, loc := range promoted {
+		locs[keyPath] = loc
+	}
+}
+
+// deleteLocs removes the locations of the key at the dotted path and all it's nested keys
+func deleteLocs(locs map[string]SourceLoc, keyPath string) {
+	delete(locs, keyPath)
+	for k := range locs {
+		if strings.HasPrefix(k, keyPath+".") {
+			delete(locs, k)
+		}
+	}
+}
+
+func hasNestedLocs(locs map[string]SourceLoc, keyPath string) bool {
+	for k := range locs {
+		if strings.HasPrefix(k, keyPath+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keyPathOf returns the dotted path of the keys
+func keyPathOf(path []interface{}) string {
+	keyPath := ""
+	for _, key := range path {
+		keyPath = joinKeyPath(keyPath, key)
+	}
+
+	return keyPath
+}
+
+// locate records the locations of the leaf keys of the mapping node, the keys without a position in the source,
+// like the keys of the under path, are located at their values
+func locate(n *node, path, file string, locs map[string]SourceLoc) {
+	for _, key := range n.keys {
+		value := n.values[key]
+		keyPath := joinKeyPath(path, key)
+		if value != nil && value.kind == mappingNode {
+			// mappings are merged, so an empty one sets nothing if the keys are already set
+			if len(value.keys) > 0 {
+				delete(locs, keyPath)
+				locate(value, keyPath, file, locs)
+				continue
+			}
+			if hasNestedLocs(locs, keyPath) {
+				continue
+			}
+		}
+		deleteLocs(locs, keyPath)
+		pos, ok := n.keyPos[key]
+		if !ok && value != nil {
+			pos = position{line: value.line, column: value.column}
+		}
+		locs[keyPath] = SourceLoc{File: file, Line: pos.line, Column: pos.column}
+	}
+}
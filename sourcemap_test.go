@@ -0,0 +1,94 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceMap(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n  - {resource: db.yml}\n  - {resource: bundle.yml, document: 1}\n\n" +
+			"name: app\ndb:\n  # the host of primary\n  host: primary\nlimits: {cpu: 2, \"memory\": 512}\n"),
+		"db.yml":     []byte("db:\n  host: localhost\n  port: 5432\n  pool:\n    size: 10\n"),
+		"bundle.yml": []byte("kind: first\n---\nkind: second\ntags:\n  - a\n  - b\n"),
+	}
+	reader := mapReader(files)
+
+	locs, err := SourceMap("config.yml", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]SourceLoc{
+		"name":          {File: "config.yml", Line: 5, Column: 1},
+		"db.host":       {File: "config.yml", Line: 8, Column: 3},
+		"db.port":       {File: "db.yml", Line: 3, Column: 3},
+		"db.pool.size":  {File: "db.yml", Line: 5, Column: 5},
+		"limits.cpu":    {File: "config.yml", Line: 9, Column: 10},
+		"limits.memory": {File: "config.yml", Line: 9, Column: 18},
+		"kind":          {File: "bundle.yml", Line: 3, Column: 1},
+		"tags":          {File: "bundle.yml", Line: 4, Column: 1},
+	}, locs)
+
+	_, err = SourceMap("missing.yml", reader)
+	assert.EqualError(t, err, "no such file")
+}
+
+func TestSourceMapPipeline(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n  - {resource: db.yml, under: storage}\n  - {resource: app.yml, unwrap: true}\n" +
+			"  - {resource: bundle.yml, match: {kind: second}}\n  - {resource: limits.json}\n" +
+			"name: {{ .Name }}\nhost: ${HOST}\n"),
+		"db.yml":      []byte("db:\n  host: localhost\n"),
+		"app.yml":     []byte("imports: []\napp:\n  # the port of the app\n  port: 8080\n"),
+		"bundle.yml":  []byte("kind: first\nfirst: 1\n---\nkind: second\nsecond: 2\n"),
+		"limits.json": []byte("{\n  \"limits\": {\n    \"cpu\": 2\n  }\n}\n"),
+	}
+	reader := mapReader(files)
+
+	locs, err := SourceMap("config.yml", reader, WithTemplateData(map[string]string{"Name": "app"}),
+		WithEnvSubstitution(), WithEnvLookup(func(name string) (string, bool) {
+			return "primary", true
+		}))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]SourceLoc{
+		"name":            {File: "config.yml", Line: 6, Column: 1},
+		"host":            {File: "config.yml", Line: 7, Column: 1},
+		"storage.db.host": {File: "db.yml", Line: 2, Column: 3},
+		"port":            {File: "app.yml", Line: 4, Column: 3},
+		"kind":            {File: "bundle.yml", Line: 4, Column: 1},
+		"second":          {File: "bundle.yml", Line: 5, Column: 1},
+		"limits.cpu":      {File: "limits.json", Line: 3, Column: 5},
+	}, locs)
+
+	files["app.yml"] = []byte("app:\n  port: 8080\nextra: 1\n")
+	_, err = SourceMap("config.yml", reader)
+	assert.True(t, errors.Is(err, UnwrapErr))
+}
+
+func TestSourceMapNodes(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n  - {resource: envs.yml}\n  - {resource: secret.yml}\n" +
+			"switches:\n  on: 1\n  n: 2\nbase: &base\n  host: localhost\nservice:\n  <<: *base\n  port: 80\n"),
+		"envs.yml":   []byte("name: app\nenvs:\n  prod:\n    name: prod\n    db: {host: primary}\n  dev:\n    name: dev\n"),
+		"secret.yml": []byte("password: !vault secret/db#password\n"),
+	}
+	reader := mapReader(files)
+
+	locs, err := SourceMap("config.yml", reader, WithActiveSection("envs", "prod"),
+		WithSecretResolver(fakeSecretResolver{"secret/db#password": "s3cr3t"}))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]SourceLoc{
+		"switches.true":  {File: "config.yml", Line: 5, Column: 3},
+		"switches.false": {File: "config.yml", Line: 6, Column: 3},
+		"base.host":      {File: "config.yml", Line: 8, Column: 3},
+		"service.host":   {File: "config.yml", Line: 8, Column: 3},
+		"service.port":   {File: "config.yml", Line: 11, Column: 3},
+		"name":           {File: "envs.yml", Line: 4, Column: 5},
+		"db.host":        {File: "envs.yml", Line: 5, Column: 10},
+		"password":       {File: "secret.yml", Line: 1, Column: 1},
+	}, locs)
+
+	files["secret.yml"] = []byte("password: !vault missing\n")
+	_, err = SourceMap("config.yml", reader, WithSecretResolver(fakeSecretResolver{}))
+	assert.NotNil(t, err, "the secrets are resolved as in processing")
+}
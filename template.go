@@ -0,0 +1,25 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// render executes the raw file as a template with the data, if it's set
+func (o *options) render(resource string, raw []byte) ([]byte, error) {
+	if o.templateData == nil {
+		return raw, nil
+	}
+
+	tmpl, err := template.New(resource).Option("missingkey=error").Parse(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", resource, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, o.templateData); err != nil {
+		return nil, fmt.Errorf("%s: %w", resource, err)
+	}
+
+	return buf.Bytes(), nil
+}
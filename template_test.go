@@ -0,0 +1,39 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTemplateData(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: {{ .Region }}.yml}\nname: app-{{ .Region }}"),
+		"eu.yml": []byte("{{ if .Debug }}log_level: debug{{ else }}log_level: info{{ end }}\nhosts:\n" +
+			"{{ range .Hosts }}  - {{ . }}\n{{ end }}"),
+		"broken.yml":  []byte("name: {{ .Region "),
+		"missing.yml": []byte("name: {{ .Zone }}"),
+	}
+	reader := mapReader(files)
+	data := map[string]interface{}{"Region": "eu", "Debug": true, "Hosts": []string{"a.eu", "b.eu"}}
+
+	var ts struct {
+		Name     string
+		LogLevel string `yaml:"log_level"`
+		Hosts    []string
+	}
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithTemplateData(data))
+	assert.Nil(t, err)
+	assert.Equal(t, "app-eu", ts.Name)
+	assert.Equal(t, "debug", ts.LogLevel)
+	assert.Equal(t, []string{"a.eu", "b.eu"}, ts.Hosts)
+
+	err = ProcessFileWithImports("broken.yml", &ts, WithReader(reader), WithTemplateData(data))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "broken.yml: template: broken.yml:1:")
+
+	err = ProcessFileWithImports("missing.yml", &ts, WithReader(reader), WithTemplateData(data))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "missing.yml: template: missing.yml:1:")
+	assert.Contains(t, err.Error(), "map has no entry for key \"Zone\"")
+}
@@ -0,0 +1,122 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// nodeTransform changes the node tree of a document before it's decoded, reporting if anything was changed
+type nodeTransform func(root *node, resource string) (changed bool, err error)
+
+// nodeTransforms returns the transforms enabled by the options, in the order they are applied
+func (o *options) nodeTransforms(dst interface{}) []nodeTransform {
+	var transforms []nodeTransform
+	if o.secretResolver != nil {
+		transforms = append(transforms, o.resolveSecrets)
+	}
+	if o.trimStringValues {
+		transforms = append(transforms, trimStringValues)
+	}
+	if o.preserveBoolStrings {
+		transforms = append(transforms, preserveBoolStrings(reflect.TypeOf(dst)))
+	}
+	if o.typeCoercion {
+		transforms = append(transforms, coerceTypes(reflect.TypeOf(dst)))
+	}
+
+	return transforms
+}
+
+// trimStringValues trims leading and trailing whitespace of all string scalars
+func trimStringValues(root *node, _ string) (bool, error) {
+	changed := false
+	err := root.walk(func(n *node) error {
+		if n.kind == scalarNode && n.tag == strTag {
+			if trimmed := strings.TrimSpace(n.text); trimmed != n.text {
+				n.text, changed = trimmed, true
+			}
+		}
+		return nil
+	})
+
+	return changed, err
+}
+
+// scalarFunc changes the scalar node decoded into the value of type t, path is the dotted path of the key
+type scalarFunc func(n *node, t reflect.Type, path string) (bool, error)
+
+// walkScalars calls fn for every scalar of the node tree decoded into the value of type t, with the type of the value
+// the scalar is decoded into. Nodes which don't match the type are skipped, as well as the values of custom unmarshalers
+func walkScalars(n *node, t reflect.Type, path string, fn scalarFunc) (bool, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if n == nil || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return false, nil
+	}
+
+	switch n.kind {
+	case mappingNode:
+		changed := false
+		for _, key := range n.keys {
+			var valueType reflect.Type
+			switch t.Kind() {
+			case reflect.Map:
+				valueType = t.Elem()
+			case reflect.Interface:
+				valueType = t
+			case reflect.Struct:
+				if name, ok := key.(string); ok {
+					valueType = structFieldType(t, name)
+				}
+			}
+			if valueType == nil {
+				continue
+			}
+			c, err := walkScalars(n.values[key], valueType, joinKeyPath(path, key), fn)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+		return changed, nil
+	case sequenceNode:
+		itemType := t
+		switch t.Kind() {
+		case reflect.Slice, reflect.Array:
+			itemType = t.Elem()
+		case reflect.Interface:
+		default:
+			return false, nil
+		}
+		changed := false
+		for i, item := range n.items {
+			c, err := walkScalars(item, itemType, fmt.Sprintf("%s[%d]", path, i), fn)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+		return changed, nil
+	}
+
+	return fn(n, t, path)
+}
+
+// preserveBoolStrings returns the transform keeping YAML 1.1 booleans like yes, no, on and off as strings,
+// when they are decoded into interface values, e.g. of map[string]interface{} fields
+func preserveBoolStrings(dst reflect.Type) nodeTransform {
+	return func(root *node, _ string) (bool, error) {
+		return walkScalars(root, dst, "", func(n *node, t reflect.Type, _ string) (bool, error) {
+			if t.Kind() != reflect.Interface || n.tag != "!!bool" {
+				return false, nil
+			}
+			if lower := strings.ToLower(n.text); lower == "true" || lower == "false" {
+				return false, nil
+			}
+			n.tag = strTag
+			return true, nil
+		})
+	}
+}
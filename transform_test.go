@@ -0,0 +1,79 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTrimStringValues(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\nname: ' base '"),
+		"config2.yml": []byte("url: \"http://example.com/   \"\nmode: \"\\tstrict \"\nport: 80\nscript: |\n  echo\n"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name, URL, Mode, Script string
+		Port                    int
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithTrimStringValues())
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{Name: "base", URL: "http://example.com/", Mode: "strict", Script: "echo", Port: 80}, ts)
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com/   ", ts.URL, "values must not be trimmed by default")
+}
+
+func TestWithTrimStringValuesMergeKeys(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("defaults: &defaults\n  url: ' http://example.com '\n  port: 80\n" +
+			"service:\n  <<: *defaults\n  name: ' api '\n"),
+	}
+	type service struct {
+		Name, URL string
+		Port      int
+	}
+	var ts struct{ Service service }
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(mapReader(files)), WithTrimStringValues())
+	assert.Nil(t, err)
+	assert.Equal(t, service{Name: "api", URL: "http://example.com", Port: 80}, ts.Service)
+}
+
+func TestWithPreserveBoolStrings(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: countries.yml}\nenabled: yes\n" +
+			"labels:\n  logging: on\n  debug: true\n  nested: {answer: N}\nextra: off"),
+		"countries.yml": []byte("labels:\n  country: no\n  list: [yes, 'no', Off]"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Enabled bool
+		Labels  map[string]interface{}
+		Extra   interface{}
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, false, ts.Labels["country"])
+	assert.Equal(t, true, ts.Labels["logging"])
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithPreserveBoolStrings())
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{
+		Enabled: true,
+		Labels: map[string]interface{}{
+			"country": "no",
+			"list":    []interface{}{"yes", "no", "Off"},
+			"logging": "on",
+			"debug":   true,
+			"nested":  map[interface{}]interface{}{"answer": "N"},
+		},
+		Extra: "off",
+	}, ts)
+}
@@ -0,0 +1,386 @@
+package yaml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	notMappingErr = errors.New("config document is not a mapping")
+
+	// directiveKeys are the keys of config file which are instructions for the loader, not config values
+	directiveKeys = importsDirectives(defaultImportsKey)
+
+	jsonNumberRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+)
+
+// numberNode decodes any YAML value the same way as interface{} does, but keeps numbers as json.Number
+type numberNode struct {
+	value interface{}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (n *numberNode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value interface{}
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+
+	switch value.(type) {
+	case map[interface{}]interface{}:
+		var nodes map[interface{}]numberNode
+		if err := unmarshal(&nodes); err != nil {
+			return err
+		}
+		m := make(map[interface{}]interface{}, len(nodes))
+		for key, node := range nodes {
+			m[key] = node.value
+		}
+		n.value = m
+	case []interface{}:
+		var nodes []numberNode
+		if err := unmarshal(&nodes); err != nil {
+			return err
+		}
+		s := make([]interface{}, len(nodes))
+		for i, node := range nodes {
+			s[i] = node.value
+		}
+		n.value = s
+	case int, int64, uint64, float64:
+		var text string
+		if err := unmarshal(&text); err != nil {
+			return err
+		}
+		// YAML-only notations like 0x1F or .inf are not valid JSON numbers, keep them resolved
+		if jsonNumberRe.MatchString(text) {
+			n.value = json.Number(text)
+		} else {
+			n.value = value
+		}
+	default:
+		n.value = value
+	}
+
+	return nil
+}
+
+// importsDirectives returns the directive keys of the loader named after the imports key
+func importsDirectives(key string) []string {
+	return []string{key, "required_" + key, "inherit_" + key}
+}
+
+// decodeTree decodes config file into generic tree, the imports directives are removed from it
+func decodeTree(raw []byte, o *options) (map[interface{}]interface{}, error) {
+	var tree map[interface{}]interface{}
+	if !o.jsonNumbers {
+		if err := yaml.Unmarshal(raw, &tree); err != nil {
+			return nil, err
+		}
+	} else {
+		var root numberNode
+		if err := yaml.Unmarshal(raw, &root); err != nil {
+			return nil, err
+		}
+		if root.value != nil {
+			var ok bool
+			if tree, ok = root.value.(map[interface{}]interface{}); !ok {
+				return nil, notMappingErr
+			}
+		}
+	}
+
+	for _, directive := range o.directives() {
+		delete(tree, directive)
+	}
+
+	return tree, nil
+}
+
+// mergeTree recursively merges src into dst: nested maps are merged key by key,
+// any other values from src, including slices, override the ones in dst
+func mergeTree(dst, src map[interface{}]interface{}) {
+	for key, srcValue := range src {
+		srcMap, srcIsMap := srcValue.(map[interface{}]interface{})
+		dstMap, dstIsMap := dst[key].(map[interface{}]interface{})
+		if srcIsMap && dstIsMap {
+			mergeTree(dstMap, srcMap)
+			continue
+		}
+		dst[key] = srcValue
+	}
+}
+
+// decodeDocument decodes the document into dst. yaml.Unmarshal replaces the nested mappings of map dst,
+// so the document is decoded into a new map, which is merged into dst recursively, without the directives
+// In strict mode the keys which don't match any field of struct dst and duplicate keys are errors
+// directives are the keys of the loader instructions, which are not the values of dst
+func decodeDocument(document []byte, dst interface{}, strict bool, directives []string) error {
+	unmarshal := yaml.Unmarshal
+	if strict {
+		unmarshal = yaml.UnmarshalStrict
+	}
+	v := reflect.ValueOf(dst).Elem()
+	if v.Kind() != reflect.Map {
+		if strict {
+			return allowDirectives(unmarshal(document, dst), v.Type(), directives)
+		}
+		return unmarshal(document, dst)
+	}
+
+	src := reflect.New(v.Type())
+	if err := unmarshal(document, src.Interface()); err != nil {
+		// the values of the directives may not fit the map values type, then they are removed before decoding
+		stripped, ok := removeDirectives(document, directives)
+		if !ok {
+			return err
+		}
+		src = reflect.New(v.Type())
+		if err := unmarshal(stripped, src.Interface()); err != nil {
+			return err
+		}
+	}
+	if src.Elem().IsNil() {
+		return nil
+	}
+	for _, directive := range directives {
+		if key := reflect.ValueOf(directive); key.Type().ConvertibleTo(v.Type().Key()) {
+			src.Elem().SetMapIndex(key.Convert(v.Type().Key()), reflect.Value{})
+		}
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	mergeMap(v, src.Elem())
+
+	return nil
+}
+
+// removeDirectives returns the mapping document without the directives, false if it has none of them
+func removeDirectives(document []byte, directives []string) ([]byte, bool) {
+	root, err := decodeNode(document)
+	if err != nil || root == nil || root.kind != mappingNode {
+		return nil, false
+	}
+	removed := false
+	for _, directive := range directives {
+		if _, ok := root.values[directive]; ok {
+			root.removeKey(directive)
+			removed = true
+		}
+	}
+
+	return encodeNode(root), removed
+}
+
+// allowDirectives drops the errors about the directives of the loader from the errors of strict decoding into
+// the struct of type t, they are not the fields of the struct
+func allowDirectives(err error, t reflect.Type, directives []string) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+
+	var errs []string
+	for _, message := range typeErr.Errors {
+		directive := false
+		for _, key := range directives {
+			directive = directive || strings.HasSuffix(message, fmt.Sprintf(": field %s not found in type %s", key, t))
+		}
+		if !directive {
+			errs = append(errs, message)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &yaml.TypeError{Errors: errs}
+}
+
+// mergeMap recursively merges src map into dst map of the same type: nested maps are merged key by key,
+// any other values from src, including slices, override the ones in dst
+func mergeMap(dst, src reflect.Value) {
+	for _, key := range src.MapKeys() {
+		srcValue, dstValue := src.MapIndex(key), dst.MapIndex(key)
+		if srcMap, dstMap := mapValue(srcValue), mapValue(dstValue); srcMap.IsValid() && dstMap.IsValid() {
+			if srcMap.Type() == dstMap.Type() {
+				mergeMap(dstMap, srcMap)
+				continue
+			}
+		}
+		dst.SetMapIndex(key, srcValue)
+	}
+}
+
+// mapValue returns the non-nil map held by the value or the interface, or invalid value if it's not a map
+func mapValue(v reflect.Value) reflect.Value {
+	if v.IsValid() && v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Kind() != reflect.Map || v.IsNil() {
+		return reflect.Value{}
+	}
+
+	return v
+}
+
+// containsTree checks if merging src into dst changes nothing: every leaf of src is already set in dst to equal value
+func containsTree(dst, src map[interface{}]interface{}) bool {
+	for key, srcValue := range src {
+		dstValue, present := dst[key]
+		if !present {
+			return false
+		}
+		srcMap, srcIsMap := srcValue.(map[interface{}]interface{})
+		dstMap, dstIsMap := dstValue.(map[interface{}]interface{})
+		if srcIsMap && dstIsMap {
+			if !containsTree(dstMap, srcMap) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(srcValue, dstValue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// yamlFieldKey returns the key of a struct field in YAML mapping, following yaml.v2 rules
+// Unexported and `yaml:"-"` fields are skipped
+func yamlFieldKey(field reflect.StructField) (key string, inline bool, skip bool) {
+	if field.PkgPath != "" && !field.Anonymous {
+		return "", false, true
+	}
+	tag := field.Tag.Get("yaml")
+	if tag == "" && !strings.Contains(string(field.Tag), ":") {
+		tag = string(field.Tag)
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	fields := strings.Split(tag, ",")
+	for _, flag := range fields[1:] {
+		if flag == "inline" {
+			inline = true
+		}
+	}
+	if fields[0] != "" {
+		return fields[0], inline, false
+	}
+
+	return strings.ToLower(field.Name), inline, false
+}
+
+// hasConfigFlag checks if the struct field has the flag in it's `config` tag
+func hasConfigFlag(field reflect.StructField, flag string) bool {
+	for _, f := range strings.Split(field.Tag.Get("config"), ",") {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setDynamicFields fills the fields of interface{}, []interface{} and map[string]interface{} types
+// with the values from the merged tree, instead of ones decoded from separate files
+// The values of map v are filled the same way as the fields of struct v
+func setDynamicFields(v reflect.Value, tree map[interface{}]interface{}) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Map {
+		setDynamicValues(v, tree)
+		return
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, inline, skip := yamlFieldKey(t.Field(i))
+		if skip {
+			continue
+		}
+		field := v.Field(i)
+		if inline {
+			setDynamicFields(field, tree)
+			continue
+		}
+		if value, present := tree[key]; present {
+			setDynamicValue(field, value)
+		}
+	}
+}
+
+// setDynamicValues fills the values of the map like setDynamicFields does, the string keys of the map
+// match the tree keys of any type, as yaml.v2 decodes 1: one into map[string]string
+func setDynamicValues(m reflect.Value, tree map[interface{}]interface{}) {
+	byString := make(map[string]interface{}, len(tree))
+	for key, value := range tree {
+		byString[fmt.Sprint(key)] = value
+	}
+
+	for _, key := range m.MapKeys() {
+		value, present := tree[key.Interface()]
+		if !present && key.Kind() == reflect.String {
+			value, present = byString[key.String()]
+		}
+		if !present {
+			continue
+		}
+		// the map values aren't addressable, so the value is filled in a copy
+		elem := reflect.New(m.Type().Elem()).Elem()
+		elem.Set(m.MapIndex(key))
+		setDynamicValue(elem, value)
+		m.SetMapIndex(key, elem)
+	}
+}
+
+// setDynamicValue fills the value of interface{}, []interface{} or map[string]interface{} type with the tree value,
+// the structs and the maps of other types are filled recursively
+func setDynamicValue(v reflect.Value, value interface{}) {
+	switch {
+	case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+		if value == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+	case v.Type() == reflect.TypeOf([]interface{}(nil)):
+		if s, ok := value.([]interface{}); ok {
+			v.Set(reflect.ValueOf(s))
+		}
+	case v.Type() == reflect.TypeOf(map[string]interface{}(nil)):
+		if m, ok := value.(map[interface{}]interface{}); ok {
+			v.Set(reflect.ValueOf(stringKeys(m)))
+		}
+	default:
+		if subtree, ok := value.(map[interface{}]interface{}); ok {
+			setDynamicFields(v, subtree)
+		}
+	}
+}
+
+// stringKeys converts the mapping of generic tree to map with string keys, nested mappings are left as is
+func stringKeys(m map[interface{}]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		result[fmt.Sprint(key)] = value
+	}
+
+	return result
+}
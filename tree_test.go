@@ -0,0 +1,93 @@
+package yaml
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeTree(t *testing.T) {
+	testCases := []struct {
+		dst      map[interface{}]interface{}
+		src      map[interface{}]interface{}
+		expected map[interface{}]interface{}
+	}{
+		{
+			map[interface{}]interface{}{"a": 1, "b": "kept"},
+			map[interface{}]interface{}{"a": 2},
+			map[interface{}]interface{}{"a": 2, "b": "kept"},
+		},
+		{
+			map[interface{}]interface{}{"a": map[interface{}]interface{}{"b": 1, "c": 1}},
+			map[interface{}]interface{}{"a": map[interface{}]interface{}{"b": 2}},
+			map[interface{}]interface{}{"a": map[interface{}]interface{}{"b": 2, "c": 1}},
+		},
+		{
+			map[interface{}]interface{}{"a": []interface{}{1, 2}, "b": map[interface{}]interface{}{"c": 1}},
+			map[interface{}]interface{}{"a": []interface{}{3}, "b": "scalar"},
+			map[interface{}]interface{}{"a": []interface{}{3}, "b": "scalar"},
+		},
+	}
+
+	for _, tc := range testCases {
+		mergeTree(tc.dst, tc.src)
+		assert.Equal(t, tc.expected, tc.dst)
+	}
+}
+
+func TestWithJSONNumbers(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\n" +
+			"values:\n  big: 9999999999999999999\n  hex: 0x1F\n" +
+			"any: 0.10000000000000000000001"),
+		"config2.yml": []byte("values:\n  big: 1\n  small: 42\n  name: text\n" +
+			"list: [1.50, 12345678901234567890123]"),
+	}
+	reader := mapReader(files)
+
+	var ts struct {
+		Values map[string]interface{}
+		List   []interface{}
+		Any    interface{}
+	}
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithJSONNumbers())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"big":   json.Number("9999999999999999999"),
+		"hex":   31,
+		"small": json.Number("42"),
+		"name":  "text",
+	}, ts.Values)
+	assert.Equal(t, []interface{}{json.Number("1.50"), json.Number("12345678901234567890123")}, ts.List)
+	assert.Equal(t, json.Number("0.10000000000000000000001"), ts.Any)
+
+	out, err := json.Marshal(ts.Values["big"])
+	assert.Nil(t, err)
+	assert.Equal(t, "9999999999999999999", string(out))
+}
+
+func TestWithJSONNumbersMapDst(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: base.yml}\nid: 9007199254740993\n" +
+			"nested: {big: 12345678901234567890}\nname: text"),
+		"base.yml": []byte("id: 1\nratio: 0.5\n"),
+	}
+
+	var dst map[string]interface{}
+	err := ProcessFileWithImports("config.yml", &dst, WithReader(mapReader(files)), WithJSONNumbers())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":     json.Number("9007199254740993"),
+		"ratio":  json.Number("0.5"),
+		"nested": map[interface{}]interface{}{"big": json.Number("12345678901234567890")},
+		"name":   "text",
+	}, dst)
+
+	var nested map[string]map[string]interface{}
+	err = ProcessFileWithImports("config.yml", &nested, WithReader(mapReader(map[string][]byte{
+		"config.yml": []byte("service: {id: 9007199254740993}"),
+	})), WithJSONNumbers())
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), nested["service"]["id"])
+}
@@ -0,0 +1,105 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	EmptyValueErr      = errors.New("field tagged as nonempty has empty value")
+	UnexportedFieldErr = errors.New("unexported field with yaml tag can't be set")
+)
+
+// validateNonEmpty checks that nonempty string fields present in the merged tree have non-empty values
+// Fields absent in the tree are not checked
+func validateNonEmpty(v reflect.Value, tree map[interface{}]interface{}, path string) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, inline, skip := yamlFieldKey(field)
+		if skip {
+			continue
+		}
+		if inline {
+			if err := validateNonEmpty(v.Field(i), tree, path); err != nil {
+				return err
+			}
+			continue
+		}
+		value, present := tree[key]
+		if !present {
+			continue
+		}
+		fieldPath := joinKeyPath(path, key)
+		if field.Type.Kind() == reflect.String && hasConfigFlag(field, "nonempty") && v.Field(i).Len() == 0 {
+			return fmt.Errorf("%s: %w", fieldPath, EmptyValueErr)
+		}
+		if subtree, ok := value.(map[interface{}]interface{}); ok {
+			if err := validateNonEmpty(v.Field(i), subtree, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkUnexportedFields reports the unexported fields of dst which have yaml tag, so they are expected to be loaded
+// The fields are listed in result warnings, or the first of them fails processing in strict mode
+func checkUnexportedFields(dst interface{}, o *options) error {
+	fields := unexportedTaggedFields(reflect.TypeOf(dst), "", map[reflect.Type]bool{})
+	if len(fields) == 0 {
+		return nil
+	}
+	if o.strict {
+		return fmt.Errorf("%s: %w", fields[0], UnexportedFieldErr)
+	}
+	if o.result != nil {
+		for _, field := range fields {
+			o.result.Warnings = append(o.result.Warnings, fmt.Sprintf("%s: %s", field, UnexportedFieldErr))
+		}
+	}
+
+	return nil
+}
+
+// unexportedTaggedFields walks the struct type and returns paths of the unexported fields with yaml tag
+func unexportedTaggedFields(t reflect.Type, path string, visited map[reflect.Type]bool) []string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+		if field.PkgPath != "" && !field.Anonymous {
+			if tag := field.Tag.Get("yaml"); tag != "" && tag != "-" {
+				fields = append(fields, fieldPath)
+			}
+			continue
+		}
+		fields = append(fields, unexportedTaggedFields(field.Type, fieldPath, visited)...)
+	}
+
+	return fields
+}
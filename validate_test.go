@@ -0,0 +1,115 @@
+package yaml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithNonEmptyValidation(t *testing.T) {
+	files := map[string][]byte{
+		"empty_by_import.yml": []byte("imports:\n - {resource: empty.yml}\nname: base"),
+		"empty.yml":           []byte("db:\n  host: ''"),
+		"properly_set.yml":    []byte("imports:\n - {resource: empty.yml}\ndb:\n  host: localhost"),
+		"absent.yml":          []byte("name: base"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name string `config:"nonempty"`
+		DB   struct {
+			Host string `yaml:"host" config:"nonempty"`
+			User string `yaml:"user"`
+		} `yaml:"db"`
+	}
+
+	testCases := []struct {
+		testFile      string
+		expectedHost  string
+		expectedError string
+	}{
+		{"empty_by_import.yml", "", "db.host: field tagged as nonempty has empty value"},
+		{"properly_set.yml", "localhost", ""},
+		{"absent.yml", "", ""},
+	}
+
+	for _, tc := range testCases {
+		var ts testStruct
+		err := ProcessFileWithImports(tc.testFile, &ts, WithReader(reader), WithNonEmptyValidation())
+		if tc.expectedError == "" {
+			assert.Nil(t, err, tc.testFile)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.testFile)
+			assert.True(t, errors.Is(err, EmptyValueErr), tc.testFile)
+		}
+		assert.Equal(t, tc.expectedHost, ts.DB.Host, tc.testFile)
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("empty_by_import.yml", &ts, WithReader(reader))
+	assert.Nil(t, err, "validation must be disabled by default")
+}
+
+func TestUnexportedFieldsWarning(t *testing.T) {
+	reader := func(filename string) ([]byte, error) {
+		return []byte("name: base\nsecret: s3cr3t\ndb:\n  password: pass"), nil
+	}
+	type dbConfig struct {
+		password string `yaml:"password"`
+	}
+	type testStruct struct {
+		Name    string   `yaml:"name"`
+		secret  string   `yaml:"secret"`
+		cache   []string // not tagged, so isn't expected to be loaded
+		DB      dbConfig `yaml:"db"`
+		Replica *dbConfig
+	}
+
+	var (
+		ts     testStruct
+		result Result
+	)
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, "base", ts.Name)
+	assert.Equal(t, []string{
+		"secret: unexported field with yaml tag can't be set",
+		"DB.password: unexported field with yaml tag can't be set",
+		"Replica.password: unexported field with yaml tag can't be set",
+	}, result.Warnings)
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithStrict())
+	assert.True(t, errors.Is(err, UnexportedFieldErr))
+	assert.EqualError(t, err, "secret: unexported field with yaml tag can't be set")
+}
+
+func TestWithValidate(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: limits.yml}\nname: app"),
+		"limits.yml": []byte("workers: 200"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name    string
+		Workers int
+	}
+	var outOfRangeErr = errors.New("workers must be between 1 and 100")
+	calls := 0
+	validate := func(dst interface{}) error {
+		calls++
+		if ts, ok := dst.(*testStruct); ok && (ts.Workers < 1 || ts.Workers > 100) {
+			return outOfRangeErr
+		}
+		return nil
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithValidate(validate))
+	assert.Equal(t, outOfRangeErr, err, "the error is returned as is")
+	assert.Equal(t, 1, calls, "dst is validated once after all the files are merged")
+
+	files["config.yml"] = []byte("imports:\n - {resource: limits.yml}\nname: app\nworkers: 10")
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithValidate(validate))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+}
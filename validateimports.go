@@ -0,0 +1,24 @@
+package yaml
+
+// ValidateImports checks the whole imports tree of the config file without loading it into a dst
+// Every file which doesn't ignore errors is read and parsed, the problems of all the files are returned
+// as ImportErrors
+// ioutil.ReadFile is used if reader is nil
+func ValidateImports(configPath string, reader ReadFileFunc) error {
+	opts := []Option{WithPreflightValidation()}
+	if reader != nil {
+		opts = append(opts, WithReader(reader))
+	}
+	o := newOptions(opts)
+	importList, err := getReverseOrderedImports(configPath, o)
+	if err != nil {
+		return err
+	}
+
+	return o.importErrors(importList)
+}
+
+// ValidateImportsFile checks the whole imports tree of the local config file, like ValidateImports
+func ValidateImportsFile(configPath string) error {
+	return ValidateImports(configPath, nil)
+}
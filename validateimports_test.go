@@ -0,0 +1,73 @@
+package yaml
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateImports(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n" +
+			" - {resource: db.yml}\n" +
+			" - {resource: missing.yml}\n" +
+			" - {resource: optional.yml, ignore_errors: true}\n" +
+			" - {resource: broken.yml}\n" +
+			"name: app"),
+		"db.yml":     []byte("imports:\n - {resource: db_missing.yml}\ndb: {host: localhost}"),
+		"broken.yml": []byte("db: [unclosed"),
+	}
+	reader := func(filename string) ([]byte, error) {
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("open " + filename + ": no such file")
+	}
+
+	err := ValidateImports("config.yml", reader)
+	var importErrs *ImportErrors
+	if assert.True(t, errors.As(err, &importErrs)) {
+		assert.Len(t, importErrs.Errors, 3)
+	}
+	assert.EqualError(t, err, "3 imports failed: config.yml -> broken.yml: yaml: line 1: did not find expected ',' or ']'; "+
+		"config.yml -> missing.yml: open missing.yml: no such file; "+
+		"config.yml -> db.yml -> db_missing.yml: open db_missing.yml: no such file")
+	assert.True(t, errors.Is(err, ImportParseErr))
+	var importErr *ImportError
+	if assert.True(t, errors.As(err, &importErr)) {
+		assert.Equal(t, "broken.yml", importErr.Resource)
+	}
+
+	assert.EqualError(t, ValidateImports("absent.yml", reader), "1 imports failed: open absent.yml: no such file")
+
+	files["missing.yml"] = []byte("name: missing")
+	files["db_missing.yml"] = []byte("db: {host: primary}")
+	files["broken.yml"] = []byte("db: {host: replica}")
+	assert.Nil(t, ValidateImports("config.yml", reader))
+}
+
+func TestValidateImportsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaml-validate-imports")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "config.yml")
+	assert.Nil(t, ioutil.WriteFile(configPath, []byte("imports:\n - {resource: a.yml}\n - {resource: b.yml}\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte("a: [1"), 0644))
+
+	err = ValidateImportsFile(configPath)
+	var importErrs *ImportErrors
+	if assert.True(t, errors.As(err, &importErrs)) {
+		assert.Len(t, importErrs.Errors, 2)
+	}
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte("a: [1]"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "b.yml"), []byte("b: 2"), 0644))
+	assert.Nil(t, ValidateImportsFile(configPath))
+}
@@ -0,0 +1,49 @@
+package yaml
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CheckWithinRoot discovers the imports tree of the config file and returns the resolved resources of the imports
+// whose canonical paths, with symlinks evaluated, are outside of the root directory, like the repository root.
+// URLs are always outside of it. The resources found don't fail the check, only the errors of imports discovery do
+// ioutil.ReadFile is used if reader is nil
+func CheckWithinRoot(configPath, root string, reader ReadFileFunc) ([]string, error) {
+	var opts []Option
+	if reader != nil {
+		opts = append(opts, WithReader(reader))
+	}
+	importList, err := getReverseOrderedImports(configPath, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	root = canonicalPath(root)
+	var outside []string
+	for _, ci := range importList {
+		if isURL(ci.Resource) {
+			outside = append(outside, ci.Resource)
+			continue
+		}
+		rel, err := filepath.Rel(root, canonicalPath(ci.Resource))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			outside = append(outside, ci.Resource)
+		}
+	}
+
+	return outside, nil
+}
+
+// canonicalPath returns the absolute path with symlinks evaluated, the paths which don't exist on the file system,
+// like the ones of custom readers, are just made absolute
+func canonicalPath(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	if evaluated, err := filepath.EvalSymlinks(file); err == nil {
+		file = evaluated
+	}
+
+	return file
+}
@@ -0,0 +1,53 @@
+package yaml
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckWithinRoot(t *testing.T) {
+	files := map[string][]byte{
+		"repo/config.yml": []byte("imports:\n - {resource: db.yml}\n - {resource: /etc/app/local.yml, ignore_errors: true}\n" +
+			" - {resource: ../shared/common.yml}\n - {resource: sub/../sub/cache.yml}\n - {resource: 'https://config.internal/base.yml'}"),
+		"repo/db.yml":                      []byte("imports:\n - {resource: ../repo/limits.yml}"),
+		"repo/limits.yml":                  []byte("cpu: 2"),
+		"repo/sub/cache.yml":               []byte("size: 10"),
+		"shared/common.yml":                []byte("name: common"),
+		"https://config.internal/base.yml": []byte("region: eu"),
+	}
+	reader := func(filename string) ([]byte, error) {
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		if data, ok := files[filepath.Clean(filename)]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+
+	outside, err := CheckWithinRoot("repo/config.yml", "repo", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://config.internal/base.yml", "shared/common.yml", "/etc/app/local.yml"}, outside)
+
+	outside, err = CheckWithinRoot("repo/config.yml", ".", reader)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://config.internal/base.yml", "/etc/app/local.yml"}, outside)
+
+	_, err = CheckWithinRoot("missing.yml", ".", reader)
+	assert.NotNil(t, err)
+
+	// the symlinks pointing outside of the root are found
+	dir := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "repo"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "secrets.yml"), []byte("token: x"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "repo", "config.yml"), []byte("imports:\n - {resource: secrets.yml}"), 0644))
+	assert.Nil(t, os.Symlink(filepath.Join(dir, "secrets.yml"), filepath.Join(dir, "repo", "secrets.yml")))
+	outside, err = CheckWithinRoot(filepath.Join(dir, "repo", "config.yml"), filepath.Join(dir, "repo"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "repo", "secrets.yml")}, outside)
+}
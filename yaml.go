@@ -8,101 +8,871 @@ package yaml
 
 import (
 	"errors"
-	"io/ioutil"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
 	"path/filepath"
 	"reflect"
-
-	"gopkg.in/yaml.v2"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 )
 
 type (
 	configImport struct {
 		Resource     string `yaml:"resource"`
 		IgnoreErrors bool   `yaml:"ignore_errors"`
-		corrupted    bool
+		// WhenEnv is the condition of the import: NAME=value or just NAME for any non-empty value
+		WhenEnv string `yaml:"when_env"`
+		// Document and Match select the documents of multi-document file to load instead of the first one
+		Document *int                   `yaml:"document"`
+		Match    map[string]interface{} `yaml:"match"`
+		// ResourceFromKey is the dotted path of the key of the same file listing the resources to import
+		ResourceFromKey string `yaml:"resource_from_key"`
+		// StripPrefix is the dotted path of the wrapper key of the file, the mapping under it is loaded instead
+		// of the file, Unwrap does the same with the single top-level key of the file
+		StripPrefix string `yaml:"strip_prefix"`
+		Unwrap      bool   `yaml:"unwrap"`
+		// Under is the dotted path of the key the file is loaded under, instead of the root of dst
+		Under string `yaml:"under"`
+		// Priority orders the imports of the file: the ones with higher priority are applied later, so they win
+		// Imports with the same priority, 0 by default, are applied in the order of declaration
+		Priority int `yaml:"priority"`
+		// Provides lists the dotted paths of the keys the import is expected to set, they are reported
+		// in Result.Warnings if the import ignores errors, fails to load, and nothing else sets them
+		Provides []string `yaml:"provides"`
+		// Timeout overrides the global read timeout for the resource, it's a duration like 30s
+		Timeout   string `yaml:"timeout"`
+		timeout   time.Duration
+		corrupted bool
+		// err is the error of the import collected in aggregation mode
+		err error
+		// ignoredErr is the error of the import which was skipped because it ignores errors
+		ignoredErr error
+		// readFailed is set if the import failed to be read, not to be parsed
+		readFailed bool
+		// raw is the content of the file read in advance
+		raw []byte
+		// importers is the chain of the files which led to the import, from the base config
+		importers []string
 	}
 	configImports struct {
 		Imports []configImport `yaml:"imports"`
+		// RequiredImports always fail processing if they can't be loaded, ignore_errors has no effect on them
+		RequiredImports []configImport `yaml:"required_imports"`
+		InheritImports  bool           `yaml:"inherit_imports"`
 	}
 
 	ReadFileFunc func(filename string) ([]byte, error)
+
+	// StatFunc returns the file info of the config file, like os.Stat does
+	StatFunc func(filename string) (os.FileInfo, error)
+
+	// Result describes how the config file and it's imports tree were processed
+	Result struct {
+		// Skipped lists the imports which were not loaded because their conditions were not met
+		Skipped []SkippedImport
+		// Warnings are the problems which don't prevent loading, but likely are mistakes
+		Warnings []string
+		// Loaded lists the resources of the files applied to dst once each, in the order they were applied:
+		// the deepest imports first, the base file last. The files which failed to load are not listed
+		// The files read with the default reader are listed with absolute paths, the resources of custom readers
+		// are listed as they were read
+		Loaded []string
+	}
+
+	// SkippedImport is a conditional import which was not loaded
+	SkippedImport struct {
+		Resource string
+		Reason   string
+	}
 )
 
-var WrongDstTypeErr = errors.New("wrong type of dst argument: only pointer to struct is supported")
+var (
+	WrongDstTypeErr   = errors.New("wrong type of dst argument: dst must be a pointer to struct or map")
+	NilDstErr         = errors.New("wrong dst argument: dst is a nil pointer")
+	NotRegularFileErr = errors.New("config path must be a regular file")
+
+	TooManyFileImportsErr = errors.New("file declares too many imports")
+	SelfImportErr         = errors.New("entry config is imported")
+	RequiredFileErr       = errors.New("required config file is not loaded")
+	CircularImportErr     = errors.New("circular import")
+	// MaxImportDepthExceededErr is returned when the import chain is deeper than the limit set with WithMaxImportDepth
+	MaxImportDepthExceededErr = errors.New("import chain is too deep")
+	// TooManyImportsErr is returned when the imports tree has more files than the limit set with WithMaxImports
+	TooManyImportsErr = errors.New("imports tree has too many files")
+)
 
 // ProcessFileWithImports processes config file and all it's imports tree
-// Currently only pointer to struct is supported as dst argument
-// Need to implement map merging(current version does full override) to support Map dst
-func ProcessFileWithImports(configPath string, dst interface{}) error {
+// dst must be a pointer to struct or map, nested mappings of map dst are merged key by key
+func ProcessFileWithImports(configPath string, dst interface{}, opts ...Option) error {
+	if err := checkDst(dst); err != nil {
+		return err
+	}
+
+	_, err := processFile(configPath, dst, newOptions(opts))
+	return err
+}
+
+// ProcessFileWithImportsStrict processes config file and all it's imports tree like ProcessFileWithImports does
+// with WithStrict option: the keys which don't match any field of dst fail processing with the name of the file
+func ProcessFileWithImportsStrict(configPath string, dst interface{}, opts ...Option) error {
+	return ProcessFileWithImports(configPath, dst, append(opts[:len(opts):len(opts)], WithStrict())...)
+}
+
+// ProcessFileWithImportsReader processes config file and all it's imports tree read with the reader,
+// like ProcessFileWithImports does with WithReader option. ioutil.ReadFile is used if reader is nil
+func ProcessFileWithImportsReader(configPath string, dst interface{}, reader ReadFileFunc, opts ...Option) error {
+	if reader != nil {
+		opts = append(opts[:len(opts):len(opts)], WithReader(reader))
+	}
+
+	return ProcessFileWithImports(configPath, dst, opts...)
+}
+
+// ProcessWithImports processes the config supplied as bytes and all it's imports tree read with the reader,
+// ioutil.ReadFile is used if reader is nil. rootName is the name of the config, the relative imports are resolved
+// against it's directory
+func ProcessWithImports(root []byte, rootName string, dst interface{}, reader ReadFileFunc, opts ...Option) error {
+	if root == nil {
+		root = []byte{}
+	}
+	opts = append(opts[:len(opts):len(opts)], func(o *options) {
+		o.rootRaw = root
+	})
+	if reader != nil {
+		opts = append(opts, WithReader(reader))
+	}
+
+	return ProcessFileWithImports(rootName, dst, opts...)
+}
+
+// ProcessFileWithImportsVerbose processes config file and all it's imports tree, and returns the resources
+// of the files applied to dst, in the order they were applied, like Result.Loaded lists them
+func ProcessFileWithImportsVerbose(configPath string, dst interface{}, opts ...Option) (loaded []string, err error) {
+	if err := checkDst(dst); err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
+	if o.result == nil {
+		o.result = &Result{}
+	}
+	_, err = processFile(configPath, dst, o)
+
+	return o.result.Loaded, err
+}
+
+// addLoaded records the resource as loaded, unless it's already listed
+func (r *Result) addLoaded(resource string) {
+	for _, loaded := range r.Loaded {
+		if filepath.Clean(loaded) == filepath.Clean(resource) {
+			return
+		}
+	}
+	r.Loaded = append(r.Loaded, resource)
+}
+
+// loadedPath returns the absolute path of the file read with the default reader, resources of custom readers
+// and URLs are returned as is, as they aren't paths of the working directory
+func (o *options) loadedPath(resource string) string {
+	if o.customReader || isURL(resource) {
+		return resource
+	}
+	if abs, err := filepath.Abs(resource); err == nil {
+		return abs
+	}
+
+	return resource
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler: the import is either the mapping with the resource and the other
+// settings, or just the resource string
+func (ci *configImport) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var resource string
+	if err := unmarshal(&resource); err == nil {
+		*ci = configImport{Resource: resource}
+		return nil
+	}
+	type plain configImport
+
+	return unmarshal((*plain)(ci))
+}
+
+// checkDst makes sure dst is a non-nil pointer to struct or map
+// Multi-level pointers and pointers to interface are rejected, as the decoder doesn't merge the files into them
+func checkDst(dst interface{}) error {
 	v := reflect.ValueOf(dst)
-	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+	if v.Kind() != reflect.Ptr {
 		return WrongDstTypeErr
 	}
+	switch v.Type().Elem().Kind() {
+	case reflect.Struct, reflect.Map:
+	case reflect.Ptr, reflect.Interface:
+		return fmt.Errorf("%w, got %s", WrongDstTypeErr, v.Type())
+	default:
+		return WrongDstTypeErr
+	}
+	if v.IsNil() {
+		return NilDstErr
+	}
+
+	return nil
+}
+
+// processFile loads config file and all it's imports tree into dst starting from the defaults, and validates
+// the loaded dst, returning the merged tree of all the files
+func processFile(configPath string, dst interface{}, o *options) (map[interface{}]interface{}, error) {
+	if err := o.applyDefaults(dst); err != nil {
+		return nil, err
+	}
+	merged, err := loadFile(configPath, dst, o)
+	if err != nil {
+		return nil, err
+	}
+	if o.validate != nil {
+		if err := o.validate(dst); err != nil {
+			return nil, err
+		}
+	}
+
+	return merged, nil
+}
+
+// loadFile loads config file and all it's imports tree on top of the values dst has, returning the merged tree
+// of all the files
+func loadFile(configPath string, dst interface{}, o *options) (map[interface{}]interface{}, error) {
+	state := newMergeState()
+	if err := state.load(configPath, dst, o); err != nil {
+		return nil, err
+	}
+
+	return state.finish(dst, o)
+}
+
+// mergeState is the state of the files loaded on top of each other, which is checked after all of them are merged
+// The fragments of a directory are loaded into the same state, as if they were the imports of a single tree
+type mergeState struct {
+	// merged keeps the generic view of all applied files, which is used by the post-merge checks
+	merged map[interface{}]interface{}
+	// overrides logs the files setting every key of the merged tree, in apply order
+	overrides overrideLog
+	// imports are the imports of all the loaded trees
+	imports []configImport
+}
 
-	return processFile(configPath, dst, ioutil.ReadFile)
+func newMergeState() *mergeState {
+	return &mergeState{merged: make(map[interface{}]interface{}), overrides: make(overrideLog)}
 }
 
-func processFile(configPath string, dst interface{}, reader ReadFileFunc) error {
-	importList, err := getReverseOrderedImports(configPath, reader)
+// load loads config file and all it's imports tree on top of the values dst has and the files loaded before
+func (s *mergeState) load(configPath string, dst interface{}, o *options) error {
+	if o.result != nil {
+		*o.result = Result{}
+	}
+	if err := checkRegularFile(configPath, o); err != nil && !o.skipMissingRoot(err) {
+		return err
+	}
+	if err := checkUnexportedFields(dst, o); err != nil {
+		return err
+	}
+	importList, err := o.rootImports(configPath)
 	if err != nil {
 		return err
 	}
+	if err := o.checkRequiredFiles(configPath, importList); err != nil {
+		return err
+	}
+	if o.preflightValidation {
+		if err := o.importErrors(importList); err != nil {
+			return err
+		}
+	}
 
+	var (
+		merged, overrides = s.merged, s.overrides
+		// prefetched are the files read concurrently ahead of loading, which were not cached by the discovery
+		prefetched map[int]readResult
+	)
 	// process from the deepest imports to base file to allow override settings
 	for i := len(importList) - 1; i >= 0; i-- {
 		if importList[i].corrupted {
 			continue
 		}
-		currentConfigRaw, readErr := reader(importList[i].Resource)
+		if _, ok := prefetched[i]; !ok && o.parallelReads > 1 && importList[i].raw == nil {
+			prefetched = o.prefetch(importList, i)
+		}
+		var (
+			currentConfigRaw []byte
+			readErr          error
+		)
+		if r, ok := prefetched[i]; ok {
+			currentConfigRaw, readErr = r.data, r.err
+		} else {
+			currentConfigRaw, readErr = o.read(importList[i])
+		}
 		if readErr != nil {
-			if importList[i].IgnoreErrors {
+			readErr = importError(readErrorKind(readErr), i, importList[i], readErr)
+			importList[i].readFailed = true
+			if o.skipFailed(&importList[i], readErr) {
 				continue
 			}
 			return readErr
 		}
-		if yamlErr := yaml.Unmarshal(currentConfigRaw, dst); yamlErr != nil {
-			if importList[i].IgnoreErrors {
+		currentConfigRaw, documents, documentsErr := o.fileDocuments(i, importList[i], currentConfigRaw, dst)
+		if documentsErr != nil {
+			if o.skipFailed(&importList[i], documentsErr) {
 				continue
 			}
-			return yamlErr
+			return documentsErr
+		}
+		// redundant is cleared by the first value of the file which changes the merged tree
+		redundant, hasValues := true, false
+		for _, fileDocument := range documents {
+			document, deleted := fileDocument.data, fileDocument.deleted
+			var previous interface{}
+			if o.appendSlices {
+				previous = DeepCopy(dst)
+			}
+			yamlErr := decodeDocument(document, dst, o.strict, o.directives())
+			if yamlErr == nil && o.appendSlices {
+				yamlErr = appendSlices(dst, previous, document, o.directives())
+			}
+			if yamlErr != nil {
+				yamlErr = importError(ImportParseErr, i, importList[i], explainParseError(importList[i].Resource, currentConfigRaw, yamlErr))
+				if o.skipFailed(&importList[i], yamlErr) {
+					redundant = false
+					break
+				}
+				return yamlErr
+			}
+			if len(deleted) > 0 {
+				redundant = false
+			}
+			for _, path := range deleted {
+				deleteValuePath(reflect.ValueOf(dst), path)
+				deleteTreePath(merged, path)
+			}
+			if currentTree, treeErr := decodeTree(document, o); treeErr == nil {
+				if len(currentTree) > 0 {
+					hasValues = true
+					redundant = redundant && containsTree(merged, currentTree)
+				}
+				if o.warnShapeMismatch && o.result != nil {
+					o.result.Warnings = append(o.result.Warnings, overrides.shapeConflicts(merged, currentTree, "", importList[i].Resource)...)
+				}
+				overrides.record(currentTree, "", importList[i].Resource)
+				mergeTree(merged, currentTree)
+			}
+		}
+		if o.result != nil && !importList[i].corrupted && importList[i].err == nil {
+			o.result.addLoaded(o.loadedPath(importList[i].Resource))
+		}
+		if o.warnRedundantImports && o.result != nil && i > 0 && hasValues && redundant {
+			o.result.Warnings = append(o.result.Warnings, importList[i].Resource+": import sets only the values already loaded")
 		}
 	}
 
+	s.imports = append(s.imports, importList...)
+
 	return nil
 }
 
-func getReverseOrderedImports(configPath string, reader ReadFileFunc) ([]configImport, error) {
+// finish runs the checks and the steps applied to dst once all the files are merged, returning the merged tree
+func (s *mergeState) finish(dst interface{}, o *options) (map[interface{}]interface{}, error) {
+	merged := s.merged
+	if err := o.importErrors(s.imports); err != nil {
+		return nil, err
+	}
+	if o.result != nil {
+		o.result.Warnings = append(o.result.Warnings, ignoredImportWarnings(s.imports, merged)...)
+	}
+	if o.maxOverridesPerKey > 0 {
+		if err := s.overrides.check(o.maxOverridesPerKey); err != nil {
+			return nil, err
+		}
+	}
+	if err := o.promoteSection(dst, merged); err != nil {
+		return nil, err
+	}
+	if o.envBinding {
+		if err := bindEnv(reflect.ValueOf(dst), ""); err != nil {
+			return nil, err
+		}
+	}
+	if o.jsonNumbers {
+		setDynamicFields(reflect.ValueOf(dst), merged)
+	}
+	if o.nonEmptyValidation {
+		if err := validateNonEmpty(reflect.ValueOf(dst), merged, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return merged, nil
+}
+
+// fileDocument is a document of the imported file ready to be decoded into dst
+type fileDocument struct {
+	data []byte
+	// root is the node tree of data with the positions in the file, nil for the empty documents
+	// and the ones yaml.v3 fails to parse, yaml.v2 reports the errors of such documents decoding them
+	root *node
+	// deleted are the paths of the keys removed with DeleteTag
+	deleted [][]interface{}
+}
+
+// fileDocuments turns the content of the import into the documents decoded into dst, the same way for loading
+// and locating the keys: the file is rendered, converted from JSON and expanded, then it's documents are selected,
+// unwrapped, nested under the path of the import, cleared of the deleted keys and transformed
+// The expanded text of the file is returned for the error messages. JSON files are positioned in their source
+func (o *options) fileDocuments(i int, ci configImport, raw []byte, dst interface{}) ([]byte, []fileDocument, error) {
+	raw, err := o.render(ci.Resource, raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	text, err := o.convertJSONFile(ci.Resource, raw)
+	if err != nil {
+		return nil, nil, importError(ImportParseErr, i, ci, err)
+	}
+	text, _ = o.expand(text)
+	documents, lines, err := selectDocumentLines(text, ci)
+	if err == nil && o.duplicateKeysCheck {
+		for _, document := range documents {
+			if err = checkDuplicateKeys(ci.Resource, document); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, importError(ImportParseErr, i, ci, err)
+	}
+	var source *node
+	if !o.typedReader && isJSONFile(ci.Resource) && len(documents) == 1 {
+		// JSON is a valid YAML, so the nodes are decoded from the source unless it fails
+		expanded, _ := o.expand(raw)
+		source, _ = decodeNode(expanded)
+	}
+
+	transforms := o.nodeTransforms(dst)
+	result := make([]fileDocument, 0, len(documents))
+	for j, data := range documents {
+		root, err := decodeNode(data)
+		if source != nil {
+			root, err = source, nil
+		} else if err == nil {
+			root.shiftLines(lines[j])
+		}
+		if err != nil {
+			if ci.StripPrefix != "" || ci.Unwrap || ci.Under != "" {
+				return nil, nil, importError(ImportParseErr, i, ci, err)
+			}
+			if len(transforms) > 0 {
+				return nil, nil, explainParseError(ci.Resource, text, err)
+			}
+			result = append(result, fileDocument{data: data})
+			continue
+		}
+
+		changed := false
+		if ci.StripPrefix != "" || ci.Unwrap {
+			if root, err = unwrapNode(root, ci, o.directives()); err != nil {
+				return nil, nil, importError(ImportParseErr, i, ci, err)
+			}
+			changed = true
+		}
+		if nested, ok := nestNode(root, ci, o.directives()); ok {
+			root, changed = nested, true
+		}
+		var deleted [][]interface{}
+		collectDeletions(root, nil, &deleted)
+		changed = changed || len(deleted) > 0
+		for _, transform := range transforms {
+			c, err := transform(root, ci.Resource)
+			if err != nil {
+				return nil, nil, explainParseError(ci.Resource, text, err)
+			}
+			changed = changed || c
+		}
+		if changed {
+			data = encodeNode(root)
+		}
+		result = append(result, fileDocument{data: data, root: root, deleted: deleted})
+	}
+
+	return text, result, nil
+}
+
+// checkRequiredFiles makes sure all the files required with WithRequireFiles are in the imports tree and readable
+// Relative paths are resolved against the directory of the base config, as relative imports are
+func (o *options) checkRequiredFiles(configPath string, importList []configImport) error {
+	if len(o.requiredFiles) == 0 {
+		return nil
+	}
+	loaded := make(map[string]bool, len(importList))
+	for _, ci := range importList {
+		if !ci.corrupted {
+			loaded[filepath.Clean(ci.Resource)] = true
+		}
+	}
+
+	var missing []string
+	for _, file := range o.requiredFiles {
+		file = o.resolvePath(configPath, file)
+		if !loaded[filepath.Clean(file)] {
+			missing = append(missing, file)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(missing, ", "), RequiredFileErr)
+	}
+
+	return nil
+}
+
+// checkRegularFile makes sure the base config is a regular file, so a directory or a device
+// fails with a clear error instead of an opaque one from the reader
+func checkRegularFile(configPath string, o *options) error {
+	if o.rootRaw != nil {
+		return nil
+	}
+	stat := o.stat
+	if stat == nil {
+		if o.customReader {
+			return nil
+		}
+		stat = os.Stat
+	}
+
+	info, err := stat(configPath)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s", NotRegularFileErr, configPath)
+	}
+
+	return nil
+}
+
+func getReverseOrderedImports(configPath string, o *options) ([]configImport, error) {
+	importList, err := discoverImports(configImport{Resource: configPath, IgnoreErrors: false}, o)
+	// the contents, the chains and the ignored errors are kept only to load the files after the discovery
+	for i := range importList {
+		importList[i].raw, importList[i].importers, importList[i].ignoredErr, importList[i].readFailed = nil, nil, nil, false
+	}
+
+	return importList, err
+}
+
+// discoverImports returns the imports tree of the base config in breadth-first order
+func discoverImports(root configImport, o *options) ([]configImport, error) {
 	var (
-		configDir, _  = filepath.Split(configPath)
-		importList    = []configImport{{Resource: configPath, IgnoreErrors: false}}
-		currentConfig configImports
+		configPath = root.Resource
+		importList = []configImport{root}
+		// parents[i] is the index of the file which imported importList[i], -1 for the base file
+		parents = []int{-1}
+		// declared[i] is the resolved list of imports of importList[i], used by inherit_imports
+		declared = [][]configImport{nil}
+		// undefined lists the environment variables referenced in the tree, but not set
+		undefined undefinedVariables
+		// cached is the size of the files contents kept to load them without reading again
+		cached = len(root.raw)
+		// seen maps the normalized resources of the discovered imports to their indexes
+		seen = map[string]int{o.importKey(root): 0}
+		// edges link the files to all their imports, the ones discovered before included
+		edges []ImportEdge
 	)
 
+	// prefetched are the results of the reads of importList[prefetchedFrom:] done in parallel
+	var (
+		prefetched     []readResult
+		prefetchedFrom int
+	)
 	for i := 0; i < len(importList); i++ {
-		currentConfigRaw, readErr := reader(importList[i].Resource)
+		var (
+			currentConfigRaw []byte
+			readErr          error
+		)
+		if o.parallelReads > 1 {
+			// the files discovered, but not processed yet, are the next level of the tree
+			if i >= prefetchedFrom+len(prefetched) {
+				prefetched, prefetchedFrom = o.readAll(importList[i:]), i
+			}
+			currentConfigRaw, readErr = prefetched[i-prefetchedFrom].data, prefetched[i-prefetchedFrom].err
+		} else {
+			currentConfigRaw, readErr = o.read(importList[i])
+		}
+		if readErr != nil && i > 0 {
+			if data, resource, ok := o.readAlternateExtension(importList[i], readErr); ok {
+				importList[i].Resource, currentConfigRaw, readErr = resource, data, nil
+				// the file found is the one of the later direct imports, the ones of the missing file resolve to it too
+				if _, ok := seen[o.importKey(importList[i])]; !ok {
+					seen[o.importKey(importList[i])] = i
+				}
+			}
+		}
 		if readErr != nil {
-			if importList[i].IgnoreErrors {
-				importList[i].corrupted = true
+			readErr = importError(readErrorKind(readErr), i, importList[i], readErr)
+			importList[i].readFailed = true
+			if o.skipDiscoveryFailed(&importList[i], readErr) {
 				continue
 			}
 			return nil, readErr
 		}
-		if yamlErr := yaml.Unmarshal(currentConfigRaw, &currentConfig); yamlErr != nil {
-			if importList[i].IgnoreErrors {
-				importList[i].corrupted = true
+		if importList[i].raw == nil && (o.maxCacheBytes <= 0 || cached+len(currentConfigRaw) <= o.maxCacheBytes) {
+			importList[i].raw = currentConfigRaw
+			cached += len(currentConfigRaw)
+		}
+		currentConfigRaw, renderErr := o.render(importList[i].Resource, currentConfigRaw)
+		if renderErr != nil {
+			if o.skipDiscoveryFailed(&importList[i], renderErr) {
+				continue
+			}
+			return nil, renderErr
+		}
+		currentConfigRaw, convertErr := o.convertJSONFile(importList[i].Resource, currentConfigRaw)
+		if convertErr != nil {
+			convertErr = importError(ImportParseErr, i, importList[i], convertErr)
+			if o.skipDiscoveryFailed(&importList[i], convertErr) {
+				continue
+			}
+			return nil, convertErr
+		}
+		currentConfigRaw, undefinedNames := o.expand(currentConfigRaw)
+		undefined.add(importList[i].Resource, undefinedNames)
+		currentConfig, yamlErr := decodeImports(currentConfigRaw, importList[i], o.importsKey)
+		if yamlErr != nil {
+			yamlErr = importError(ImportParseErr, i, importList[i], explainParseError(importList[i].Resource, currentConfigRaw, yamlErr))
+			if o.skipDiscoveryFailed(&importList[i], yamlErr) {
 				continue
 			}
 			return nil, yamlErr
 		}
-		for i := len(currentConfig.Imports) - 1; i >= 0; i-- {
-			importFile := currentConfig.Imports[i]
-			if !filepath.IsAbs(importFile.Resource) {
-				importFile.Resource = configDir + importFile.Resource
+
+		// the required imports go first, so the optional layers override them
+		imports := append([]configImport(nil), currentConfig.RequiredImports...)
+		for _, importFile := range currentConfig.Imports {
+			importFile.IgnoreErrors = importFile.IgnoreErrors || o.ignoreAllErrors
+			imports = append(imports, importFile)
+		}
+		if o.maxImportsPerFile > 0 && len(imports) > o.maxImportsPerFile {
+			return nil, fmt.Errorf("%s declares %d imports, limit is %d: %w",
+				importList[i].Resource, len(imports), o.maxImportsPerFile, TooManyFileImportsErr)
+		}
+
+		var resolved []configImport
+		// inherited imports go first, so the file's own imports override them
+		if currentConfig.InheritImports && parents[i] >= 0 {
+			for _, inherited := range declared[parents[i]] {
+				if inherited.Resource != importList[i].Resource {
+					resolved = append(resolved, inherited)
+				}
 			}
-			importList = append(importList, importFile)
 		}
-		currentConfig.Imports = currentConfig.Imports[:0]
+		for _, declaredImport := range imports {
+			declaredImport.Resource = o.resolvePath(configPath, declaredImport.Resource)
+			expanded, globErr := o.expandGlob(declaredImport, importList[i].Resource, configPath)
+			if globErr != nil {
+				return nil, globErr
+			}
+			for _, importFile := range expanded {
+				if filepath.Clean(importFile.Resource) == filepath.Clean(configPath) {
+					if i == 0 {
+						return nil, fmt.Errorf("%s imports itself: %w", configPath, SelfImportErr)
+					}
+					return nil, fmt.Errorf("%s imports the entry config %s: %w", importList[i].Resource, configPath, SelfImportErr)
+				}
+				if importFile.Timeout != "" {
+					timeout, timeoutErr := time.ParseDuration(importFile.Timeout)
+					if timeoutErr != nil {
+						return nil, fmt.Errorf("%s: invalid timeout of %s: %w", importList[i].Resource, importFile.Resource, timeoutErr)
+					}
+					importFile.timeout = timeout
+				}
+				if reason, ok := importFile.conditionsMet(); !ok {
+					if o.result != nil {
+						o.result.Skipped = append(o.result.Skipped, SkippedImport{Resource: importFile.Resource, Reason: reason})
+					}
+					continue
+				}
+				resolved = append(resolved, importFile)
+			}
+		}
+		sort.SliceStable(resolved, func(a, b int) bool {
+			return resolved[a].Priority < resolved[b].Priority
+		})
+		resolved = o.dedupeImports(resolved)
+		declared[i] = resolved
+
+		edgesFrom := len(edges)
+		for j := len(resolved) - 1; j >= 0; j-- {
+			if cycle := importCycle(importList, parents, i, resolved[j].Resource); cycle != nil {
+				return nil, fmt.Errorf("%s: %w", strings.Join(cycle, " -> "), CircularImportErr)
+			}
+			// the file imported by several files is loaded once, at the position it's applied last at,
+			// which is the first one in breadth-first order, so the values loaded are the same
+			key := o.importKey(resolved[j])
+			if k, ok := seen[key]; ok {
+				importList[k].IgnoreErrors = importList[k].IgnoreErrors && resolved[j].IgnoreErrors
+				edges = append(edges, ImportEdge{From: i, To: k})
+				continue
+			}
+			if depth := len(importList[i].importers) + 1; o.maxImportDepth > 0 && depth > o.maxImportDepth {
+				return nil, fmt.Errorf("%s imports %s at depth %d, limit is %d: %w",
+					importList[i].Resource, resolved[j].Resource, depth, o.maxImportDepth, MaxImportDepthExceededErr)
+			}
+			if o.maxImports > 0 && len(importList) >= o.maxImports {
+				return nil, fmt.Errorf("%s imports %s, file %d of the tree, limit is %d: %w",
+					importList[i].Resource, resolved[j].Resource, len(importList)+1, o.maxImports, TooManyImportsErr)
+			}
+			seen[key] = len(importList)
+			imported := resolved[j]
+			imported.importers = append(importList[i].importers[:len(importList[i].importers):len(importList[i].importers)], importList[i].Resource)
+			edges = append(edges, ImportEdge{From: i, To: len(importList)})
+			importList = append(importList, imported)
+			parents = append(parents, i)
+			declared = append(declared, nil)
+		}
+		// the imports are discovered in reverse order
+		for a, b := edgesFrom, len(edges)-1; a < b; a, b = a+1, b-1 {
+			edges[a], edges[b] = edges[b], edges[a]
+		}
+	}
+	if o.strictSubstitution {
+		if err := undefined.err(); err != nil {
+			return nil, err
+		}
+	}
+	if o.importParents != nil {
+		*o.importParents = parents
+	}
+	if o.importEdges != nil {
+		*o.importEdges = edges
 	}
 
 	return importList, nil
 }
+
+// importKey normalizes the resource of the import to find the repeated ones, the imports of the same file
+// selecting different documents, unwrapping them or nesting them under different keys are different imports
+func (o *options) importKey(ci configImport) string {
+	key := path.Clean(ci.Resource)
+	if !o.slashPaths {
+		if abs, err := filepath.Abs(ci.Resource); err == nil {
+			key = abs
+		}
+	}
+	if ci.Document != nil {
+		key += fmt.Sprintf("\x00document %d", *ci.Document)
+	}
+	if ci.Match != nil {
+		key += fmt.Sprintf("\x00match %v", ci.Match)
+	}
+	if ci.StripPrefix != "" || ci.Unwrap {
+		key += fmt.Sprintf("\x00unwrap %s %t", ci.StripPrefix, ci.Unwrap)
+	}
+	if ci.Under != "" {
+		key += "\x00under " + ci.Under
+	}
+
+	return key
+}
+
+// dedupeImports removes the repeated imports of the same file, keeping the last occurrence, so the import
+// is applied once at the position it wins at. The repeated import ignores errors only if all the occurrences do
+func (o *options) dedupeImports(resolved []configImport) []configImport {
+	last := make(map[string]int, len(resolved))
+	for j, ci := range resolved {
+		key := o.importKey(ci)
+		if k, ok := last[key]; ok {
+			ci.IgnoreErrors = ci.IgnoreErrors && resolved[k].IgnoreErrors
+			resolved[j] = ci
+		}
+		last[key] = j
+	}
+
+	deduped := resolved[:0]
+	for j, ci := range resolved {
+		if last[o.importKey(ci)] == j {
+			deduped = append(deduped, ci)
+		}
+	}
+
+	return deduped
+}
+
+// urlRe matches the resources with a scheme, like https://host/base.yml, they are read by the reader as is
+var urlRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+// isURL checks if the resource is a URL, not a file path
+func isURL(resource string) bool {
+	return urlRe.MatchString(resource)
+}
+
+// resolvePath resolves the relative resource against the directory of the base config, the paths are cleaned,
+// so ../ and ./ segments are collapsed. URLs are kept as is
+func (o *options) resolvePath(configPath, resource string) string {
+	if isURL(resource) {
+		return resource
+	}
+	if isURL(configPath) {
+		// the remote config imports the resources relative to it's URL
+		if base, err := url.Parse(configPath); err == nil {
+			if ref, err := url.Parse(resource); err == nil {
+				return base.ResolveReference(ref).String()
+			}
+		}
+	}
+	if o.slashPaths {
+		if path.IsAbs(resource) {
+			return path.Clean(resource)
+		}
+		dir, _ := path.Split(configPath)
+		return path.Clean(dir + resource)
+	}
+	if filepath.IsAbs(resource) {
+		return filepath.Clean(resource)
+	}
+	dir, _ := filepath.Split(configPath)
+
+	return filepath.Clean(dir + resource)
+}
+
+// importCycle returns the chain of imports from the resource to itself, if the resource imported by importList[i]
+// is the file or one of the files importing it. Nil is returned otherwise, a file imported by several ones is not
+// a cycle
+func importCycle(importList []configImport, parents []int, i int, resource string) []string {
+	chain := []string{resource}
+	for ; i >= 0; i = parents[i] {
+		chain = append(chain, importList[i].Resource)
+		if filepath.Clean(importList[i].Resource) != filepath.Clean(resource) {
+			continue
+		}
+		// the chain is collected from the importing file up to the ancestor, it's reversed to the import order
+		for a, b := 0, len(chain)-1; a < b; a, b = a+1, b-1 {
+			chain[a], chain[b] = chain[b], chain[a]
+		}
+		return chain
+	}
+
+	return nil
+}
+
+// conditionsMet checks the import conditions, returning the reason if the import must be skipped
+func (ci configImport) conditionsMet() (reason string, ok bool) {
+	if ci.WhenEnv != "" {
+		name, expected := ci.WhenEnv, ""
+		if eq := strings.IndexByte(ci.WhenEnv, '='); eq >= 0 {
+			name, expected = ci.WhenEnv[:eq], ci.WhenEnv[eq+1:]
+		}
+		value := os.Getenv(name)
+		if value == "" || (expected != "" && value != expected) {
+			return "condition when_env " + ci.WhenEnv + " not met", false
+		}
+	}
+
+	return "", true
+}
@@ -8,6 +8,11 @@ package yaml
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -75,6 +80,52 @@ func TestGetReverseOrderedImports(t *testing.T) {
 			},
 			nil,
 		},
+		// the shared file of diamond imports is loaded once, with the precedence of the last import
+		{
+			map[string][]byte{
+				"config1.yml": []byte("imports:\n - {resource: config2.yml}\n - {resource: config3.yml}"),
+				"config2.yml": []byte("imports:\n - {resource: config4.yml}"),
+				"config3.yml": []byte("imports:\n - {resource: config4.yml, ignore_errors: true}"),
+				"config4.yml": []byte("no_imports: here"),
+			},
+			"config1.yml",
+			[]configImport{
+				{Resource: "config1.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "config3.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "config2.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "config4.yml", corrupted: false, IgnoreErrors: false},
+			},
+			nil,
+		},
+		// inherit_imports test cases
+		{
+			map[string][]byte{
+				"config1.yml":   []byte("imports:\n - {resource: defaults.yml}\n - {resource: service.yml}"),
+				"service.yml":   []byte("inherit_imports: true\nimports:\n - {resource: overrides.yml}"),
+				"defaults.yml":  []byte("no_imports: here"),
+				"overrides.yml": []byte("no_imports: here"),
+			},
+			"config1.yml",
+			[]configImport{
+				{Resource: "config1.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "service.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "defaults.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "overrides.yml", corrupted: false, IgnoreErrors: false},
+			},
+			nil,
+		},
+		{
+			map[string][]byte{
+				"config1.yml": []byte("inherit_imports: true\nimports:\n - {resource: config2.yml}"),
+				"config2.yml": []byte("no_imports: here"),
+			},
+			"config1.yml",
+			[]configImport{
+				{Resource: "config1.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "config2.yml", corrupted: false, IgnoreErrors: false},
+			},
+			nil,
+		},
 		// ignore_errors test cases
 		{
 			map[string][]byte{
@@ -107,6 +158,24 @@ func TestGetReverseOrderedImports(t *testing.T) {
 			},
 			nil,
 		},
+		// string imports cases
+		{
+			map[string][]byte{
+				"config1.yml": []byte("imports:\n - config2.yml\n - {resource: wrong_file.yml, ignore_errors: true}\n - \"config3.yml\""),
+				"config2.yml": []byte("imports: [config4.yml]"),
+				"config3.yml": []byte("no_imports: here"),
+				"config4.yml": []byte("no_imports: here"),
+			},
+			"config1.yml",
+			[]configImport{
+				{Resource: "config1.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "config3.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "wrong_file.yml", corrupted: true, IgnoreErrors: true},
+				{Resource: "config2.yml", corrupted: false, IgnoreErrors: false},
+				{Resource: "config4.yml", corrupted: false, IgnoreErrors: false},
+			},
+			nil,
+		},
 		// abs path cases
 		{
 			map[string][]byte{
@@ -143,11 +212,25 @@ func TestGetReverseOrderedImports(t *testing.T) {
 				return nil, fakeReaderNoFileError
 			}
 		}
-		imports, err := getReverseOrderedImports(tc.testFile, fakeReader)
+		imports, err := getReverseOrderedImports(tc.testFile, newOptions([]Option{WithReader(fakeReader)}))
 		assert.Equal(t, tc.expectedImports, imports)
-		assert.Equal(t, tc.expectedError, err)
-
+		var expectedTypeErr, typeErr *yaml.TypeError
+		switch {
+		case tc.expectedError == nil:
+			assert.Nil(t, err)
+		case errors.As(tc.expectedError, &expectedTypeErr):
+			assert.True(t, errors.As(err, &typeErr))
+			assert.Equal(t, expectedTypeErr, typeErr)
+		default:
+			assert.True(t, errors.Is(err, tc.expectedError))
+		}
 	}
+
+	_, err := getReverseOrderedImports("config1.yml", newOptions([]Option{WithReader(func(string) ([]byte, error) {
+		return []byte("not valid"), nil
+	})}))
+	assert.True(t, errors.Is(err, InvalidImportsErr))
+	assert.EqualError(t, err, "config1.yml: imports must be a list of resources: line 1: cannot unmarshal !!str `not valid`")
 }
 
 func TestProcessFile(t *testing.T) {
@@ -203,17 +286,938 @@ func TestProcessFile(t *testing.T) {
 		},
 	}
 
-	err := processFile("config1.yml", &ts, fakeReader)
+	_, err := processFile("config1.yml", &ts, newOptions([]Option{WithReader(fakeReader)}))
 	assert.Nil(t, err)
 	assert.Equal(t, expected, ts)
 
-	err = processFile("wrong_file.yml", &ts2, fakeReader)
+	_, err = processFile("wrong_file.yml", &ts2, newOptions([]Option{WithReader(fakeReader)}))
 	assert.Equal(t, empty_ts, ts2)
 	assert.Equal(t, fakeReaderNoFileError, err)
 }
 
+func TestProcessFileMapDst(t *testing.T) {
+	fakeReader := func(filename string) ([]byte, error) {
+		switch filename {
+		case "config1.yml":
+			return []byte("imports:\n" +
+				" - {resource: config2.yml}\n" +
+				"a: config1, final value\n" +
+				"f: [config1]"), nil
+		case "config2.yml":
+			return []byte("imports:\n" +
+				" - {resource: config3.yml}\n" +
+				" - {resource: wrong_file.yaml, ignore_errors: true}\n" +
+				"a: config2, will be overwritten again\n" +
+				"b:\n" +
+				" c: C value from config 2"), nil
+		case "config3.yml":
+			return []byte("" +
+				"a: config3, will be overwritten twice\n" +
+				"b:\n" +
+				" c: will be overwritten once\n" +
+				" d:\n" +
+				"  e: will not be overwritten\n" +
+				"f: [config3, will be overwritten]"), nil
+		default:
+			return nil, errors.New("no such file")
+		}
+	}
+	expected := map[string]interface{}{
+		"a": "config1, final value",
+		"b": map[interface{}]interface{}{
+			"c": "C value from config 2",
+			"d": map[interface{}]interface{}{
+				"e": "will not be overwritten",
+			},
+		},
+		"f": []interface{}{"config1"},
+	}
+
+	var dst map[string]interface{}
+	err := ProcessFileWithImports("config1.yml", &dst, WithReader(fakeReader))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, dst)
+
+	generic := map[interface{}]interface{}{"g": "preset"}
+	err = ProcessFileWithImports("config1.yml", &generic, WithReader(fakeReader))
+	assert.Nil(t, err)
+	assert.Equal(t, "preset", generic["g"])
+	assert.Equal(t, expected["b"], generic["b"])
+	assert.NotContains(t, generic, "imports")
+
+	flat := map[string]string{}
+	err = ProcessFileWithImports("config2.yml", &flat, WithReader(func(filename string) ([]byte, error) {
+		if filename == "config2.yml" {
+			return []byte("imports:\n - {resource: config3.yml}\na: config2"), nil
+		}
+		return []byte("a: config3\nc: config3"), nil
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a": "config2", "c": "config3"}, flat)
+}
+
+func TestProcessFileMergeKeys(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: base.yml}\n" +
+			"base: &b {x: 1, v: two}\nderived: {<<: *b, z: three}\n"),
+		"base.yml": []byte("derived: {w: zero}\n"),
+	}
+	reader := mapReader(files)
+
+	var generic map[string]interface{}
+	err := ProcessFileWithImports("config.yml", &generic, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, map[interface{}]interface{}{"w": "zero", "x": 1, "v": "two", "z": "three"}, generic["derived"])
+	assert.NotContains(t, generic, "imports")
+
+	var nested map[string]map[string]string
+	err = ProcessFileWithImports("config.yml", &nested, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"w": "zero", "x": "1", "v": "two", "z": "three"}, nested["derived"])
+
+	var ts struct {
+		Derived struct {
+			W, V, Z string
+			X       int
+		}
+	}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, ts.Derived.X)
+	assert.Equal(t, "zero", ts.Derived.W)
+	assert.Equal(t, "two", ts.Derived.V)
+	assert.Equal(t, "three", ts.Derived.Z)
+}
+
 func TestProcessFileWithImports(t *testing.T) {
-	unsupported := make(map[string]string)
+	unsupported := make([]string, 0)
 	err := ProcessFileWithImports("any.yml", &unsupported)
-	assert.Equal(t, WrongDstTypeErr, err, "wrong behaviour: expected to get WrongDstTypeErr when providing map")
+	assert.Equal(t, WrongDstTypeErr, err, "wrong behaviour: expected to get WrongDstTypeErr when providing slice")
+}
+
+func TestProcessFileWithImportsDst(t *testing.T) {
+	reader := func(filename string) ([]byte, error) {
+		return []byte("a: value"), nil
+	}
+	type testStruct struct{ A string }
+	var (
+		nilPointer *testStruct
+		valid      testStruct
+		validMap   map[string]string
+		validPtr   = &valid
+		iface      interface{}
+		structFace interface{} = testStruct{}
+	)
+
+	testCases := []struct {
+		name  string
+		dst   interface{}
+		error string
+	}{
+		{"nil pointer", nilPointer, "wrong dst argument: dst is a nil pointer"},
+		{"non-pointer", valid, "wrong type of dst argument: dst must be a pointer to struct or map"},
+		{"pointer to non-struct", new(string), "wrong type of dst argument: dst must be a pointer to struct or map"},
+		{"nil", nil, "wrong type of dst argument: dst must be a pointer to struct or map"},
+		{"pointer to pointer", &validPtr, "wrong type of dst argument: dst must be a pointer to struct or map, got **yaml.testStruct"},
+		{"pointer to nil pointer", &nilPointer, "wrong type of dst argument: dst must be a pointer to struct or map, got **yaml.testStruct"},
+		{"pointer to interface", &iface, "wrong type of dst argument: dst must be a pointer to struct or map, got *interface {}"},
+		{"pointer to interface with struct", &structFace, "wrong type of dst argument: dst must be a pointer to struct or map, got *interface {}"},
+		{"nil pointer to map", (*map[string]string)(nil), "wrong dst argument: dst is a nil pointer"},
+		{"pointer to nil map", &validMap, ""},
+		{"valid pointer", &valid, ""},
+	}
+
+	for _, tc := range testCases {
+		err := ProcessFileWithImports("config.yml", tc.dst, WithReader(reader))
+		if tc.error == "" {
+			assert.Nil(t, err, tc.name)
+			continue
+		}
+		assert.EqualError(t, err, tc.error, tc.name)
+	}
+	assert.Equal(t, "value", valid.A)
+	assert.Equal(t, map[string]string{"a": "value"}, validMap)
+	assert.True(t, errors.Is(ProcessFileWithImports("config.yml", nilPointer), NilDstErr))
+	assert.True(t, errors.Is(ProcessFileWithImports("config.yml", &validPtr), WrongDstTypeErr))
+}
+
+func TestStringImports(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - config2.yml\n - {resource: missing.yml, ignore_errors: true}\n - config3.yml\ndb:\n  name: app"),
+		"config2.yml": []byte("db:\n  host: localhost\n  port: 5432"),
+		"config3.yml": []byte("db:\n  port: 6432"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		DB struct {
+			Host string
+			Port int
+			Name string
+		}
+	}
+
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithStrict())
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", ts.DB.Host)
+	assert.Equal(t, 6432, ts.DB.Port)
+	assert.Equal(t, "app", ts.DB.Name)
+
+	files["config1.yml"] = []byte("imports:\n - missing.yml\n - config2.yml")
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ImportNotFoundErr), "string imports don't ignore errors")
+
+	files["config1.yml"] = []byte("imports:\n - [config2.yml]")
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader))
+	assert.NotNil(t, err, "imports are either strings or mappings")
+}
+
+func TestRepeatedImports(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - config2.yml\n - config3.yml\n - config2.yml\nname: app"),
+		"config2.yml": []byte("port: 5432"),
+		"config3.yml": []byte("port: 6432"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		Name string
+		Port int
+	}
+
+	result := Result{}
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, 5432, ts.Port, "the repeated import is applied at it's last position")
+	assert.Equal(t, []string{"config3.yml", "config2.yml", "config1.yml"}, result.Loaded)
+
+	graph, err := DiscoverImports("config1.yml", WithReader(reader))
+	if assert.Nil(t, err) {
+		assert.Len(t, graph.Nodes, 3)
+		assert.Len(t, graph.Imports(0), 2, "the repeated import is a single edge")
+	}
+
+	files["config1.yml"] = []byte("imports:\n - {resource: missing.yml, ignore_errors: true}\n - missing.yml")
+	err = ProcessFileWithImports("config1.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ImportNotFoundErr), "errors are ignored only if all the occurrences ignore them")
+}
+
+func TestConditionalImports(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n" +
+			" - {resource: prod.yml, when_env: APP_ENV=prod}\n" +
+			" - {resource: dev.yml, when_env: APP_ENV=dev}\n" +
+			" - {resource: debug.yml, when_env: APP_DEBUG}\n" +
+			"a: config1"),
+		"prod.yml":  []byte("b: prod"),
+		"dev.yml":   []byte("b: dev"),
+		"debug.yml": []byte("c: debug"),
+	}
+	reader := mapReader(files)
+
+	os.Setenv("APP_ENV", "dev")
+	os.Unsetenv("APP_DEBUG")
+	defer os.Unsetenv("APP_ENV")
+
+	var (
+		result Result
+		ts     struct{ A, B, C string }
+	)
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, struct{ A, B, C string }{"config1", "dev", ""}, ts)
+	assert.Equal(t, []SkippedImport{
+		{Resource: "prod.yml", Reason: "condition when_env APP_ENV=prod not met"},
+		{Resource: "debug.yml", Reason: "condition when_env APP_DEBUG not met"},
+	}, result.Skipped)
+}
+
+func TestProcessFileWithImportsNotRegularFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var ts struct{ A string }
+	err = ProcessFileWithImports(dir, &ts)
+	assert.True(t, errors.Is(err, NotRegularFileErr))
+	assert.EqualError(t, err, "config path must be a regular file: "+dir)
+
+	err = ProcessFileWithImports(filepath.Join(dir, "missing.yml"), &ts)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	configPath := filepath.Join(dir, "config.yml")
+	if err := ioutil.WriteFile(configPath, []byte("a: value"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = ProcessFileWithImports(configPath, &ts)
+	assert.Nil(t, err)
+	assert.Equal(t, "value", ts.A)
+
+	reader := func(filename string) ([]byte, error) {
+		return []byte("a: from reader"), nil
+	}
+	dirStat := func(filename string) (os.FileInfo, error) {
+		return os.Stat(dir)
+	}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithStat(dirStat))
+	assert.True(t, errors.Is(err, NotRegularFileErr))
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err, "custom reader without stat function must not be checked")
+	assert.Equal(t, "from reader", ts.A)
+}
+
+func TestWithMaxImportsPerFile(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: a.yml}\n - {resource: b.yml}\nname: base"),
+		"a.yml":      []byte("imports:\n - {resource: c.yml}\n - {resource: d.yml}\n - {resource: e.yml}"),
+		"b.yml":      []byte("b: value"),
+	}
+	reader := func(filename string) ([]byte, error) {
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return []byte("{}"), nil
+	}
+
+	var ts struct{ Name, B string }
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithMaxImportsPerFile(3))
+	assert.Nil(t, err)
+	assert.Equal(t, "value", ts.B)
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithMaxImportsPerFile(2))
+	assert.True(t, errors.Is(err, TooManyFileImportsErr))
+	assert.EqualError(t, err, "a.yml declares 3 imports, limit is 2: file declares too many imports")
+}
+
+func TestBlockScalarsOverride(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: scripts.yml}\n" +
+			"script: |\n  echo base\n  exit 0\n" +
+			"templates:\n  motd: |-\n    Welcome\n    to base\n  footer: |+\n    bye\n\n\n" +
+			"token: !vault secret/token\n"),
+		"scripts.yml": []byte("script: |\n  echo imported\n  echo more lines\n  echo than base\n" +
+			"templates:\n  motd: |\n    imported motd\n    with more lines\n    than base\n" +
+			"  footer: imported footer\n  header: >\n    folded\n    header\n"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Script    string
+		Templates map[string]string
+	}
+	expected := testStruct{
+		Script: "echo base\nexit 0\n",
+		Templates: map[string]string{
+			"motd":   "Welcome\nto base",
+			"footer": "bye\n\n\n",
+			"header": "folded header\n",
+		},
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, ts)
+
+	// resolving the secret re-encodes the document, the blocks must be kept exactly
+	ts = testStruct{}
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(reader),
+		WithSecretResolver(fakeSecretResolver{"secret/token": "t0ken"}))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, ts)
+
+	// the merged tree used for leftovers and post-merge checks keeps them too
+	leftovers, err := ProcessFileWithLeftovers("config.yml", &struct{ Script string }{}, WithReader(reader),
+		WithSecretResolver(fakeSecretResolver{"secret/token": "t0ken"}))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"motd":   "Welcome\nto base",
+		"footer": "bye\n\n\n",
+		"header": "folded header\n",
+	}, leftovers["templates"])
+}
+
+func TestSelfImport(t *testing.T) {
+	files := map[string][]byte{
+		"configs/config.yml": []byte("imports:\n - {resource: db.yml}\n - {resource: ./config.yml}\nname: base"),
+		"configs/app.yml":    []byte("imports:\n - {resource: nested/db.yml}"),
+		// relative imports are resolved against the directory of the entry config
+		"configs/nested/db.yml": []byte("imports:\n - {resource: app.yml, ignore_errors: true}"),
+		"configs/db.yml":        []byte("db: {host: localhost}"),
+	}
+	reader := mapReader(files)
+
+	var ts struct{ Name string }
+	err := ProcessFileWithImports("configs/config.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, SelfImportErr))
+	assert.EqualError(t, err, "configs/config.yml imports itself: entry config is imported")
+
+	err = ProcessFileWithImports("configs/app.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, SelfImportErr))
+	assert.EqualError(t, err, "configs/nested/db.yml imports the entry config configs/app.yml: entry config is imported")
+}
+
+func TestInlineStructAcrossImports(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: server.yml}\n - {resource: auth.yml}\nname: app\nport: '8080'"),
+		"server.yml": []byte("host: localhost\nport: 80\ntimeout: 5\nextra: value"),
+		"auth.yml":   []byte("user: admin\ntimeout: !delete"),
+	}
+	reader := mapReader(files)
+	type auth struct {
+		User string
+	}
+	type server struct {
+		Host    string
+		Port    int
+		Timeout int
+		auth    `yaml:",inline"`
+	}
+	type testStruct struct {
+		Name   string
+		Server server `yaml:",inline"`
+	}
+
+	var ts testStruct
+	leftovers, err := ProcessFileWithLeftovers("config.yml", &ts, WithReader(reader), WithTypeCoercion())
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{
+		Name:   "app",
+		Server: server{Host: "localhost", Port: 8080, auth: auth{User: "admin"}},
+	}, ts)
+	assert.Equal(t, map[string]interface{}{"extra": "value"}, leftovers)
+}
+
+func TestWithWarnRedundantImports(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource: defaults.yml}\n - {resource: same.yml}\n - {resource: override.yml}\n" +
+			" - {resource: only_imports.yml}\nname: app"),
+		"defaults.yml":     []byte("db:\n  host: localhost\n  port: 5432\ntags: [a, b]"),
+		"same.yml":         []byte("db:\n  port: 5432\ntags: [a, b]"),
+		"override.yml":     []byte("db:\n  port: 6432"),
+		"only_imports.yml": []byte("imports:\n - {resource: region.yml}"),
+		"region.yml":       []byte("region: eu"),
+	}
+	reader := mapReader(files)
+
+	var (
+		ts struct {
+			Name string
+			DB   struct {
+				Host string
+				Port int
+			}
+		}
+		result Result
+	)
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader), WithResult(&result), WithWarnRedundantImports())
+	assert.Nil(t, err)
+	assert.Equal(t, 6432, ts.DB.Port)
+	assert.Equal(t, []string{"same.yml: import sets only the values already loaded"}, result.Warnings)
+}
+
+func TestResourceFromKey(t *testing.T) {
+	files := map[string][]byte{
+		"config.yml": []byte("imports:\n - {resource_from_key: module_paths}\n - {resource_from_key: extra.module, ignore_errors: true}\n" +
+			"module_paths:\n - modules/db.yml\n - modules/cache.yml\nextra:\n  module: modules/missing.yml\nname: app"),
+		"modules/db.yml":    []byte("db: {host: localhost}"),
+		"modules/cache.yml": []byte("cache: {size: 10}\ndb: {port: 5432}"),
+		"invalid.yml":       []byte("imports:\n - {resource_from_key: modules}\nmodules: {db: db.yml}"),
+		"absent.yml":        []byte("imports:\n - {resource_from_key: modules}"),
+	}
+	reader := mapReader(files)
+
+	var ts struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+		Cache struct{ Size int }
+	}
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "localhost", ts.DB.Host)
+	assert.Equal(t, 5432, ts.DB.Port)
+	assert.Equal(t, 10, ts.Cache.Size)
+
+	err = ProcessFileWithImports("invalid.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ResourceKeyErr))
+	assert.EqualError(t, err, "invalid.yml: resource_from_key modules: resource_from_key must name a string or a list of strings")
+
+	err = ProcessFileWithImports("absent.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ResourceKeyErr))
+}
+
+func TestSingleFileFastPath(t *testing.T) {
+	content := "name: app\ndb:\n  host: localhost\n  port: 5432\ntags: [a, b]\n"
+	files := map[string][]byte{
+		"single.yml":  []byte(content),
+		"general.yml": []byte("imports: []\n" + content),
+	}
+	reads := make(map[string]int)
+	reader := func(filename string) ([]byte, error) {
+		reads[filename]++
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+	type testStruct struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+		Tags []string
+	}
+
+	var single, general testStruct
+	singleLeftovers, err := ProcessFileWithLeftovers("single.yml", &single, WithReader(reader))
+	assert.Nil(t, err)
+	generalLeftovers, err := ProcessFileWithLeftovers("general.yml", &general, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, general, single)
+	assert.Equal(t, generalLeftovers, singleLeftovers)
+	assert.Equal(t, map[string]int{"single.yml": 1, "general.yml": 1}, reads)
+}
+
+func BenchmarkProcessFileWithImports(b *testing.B) {
+	content := "name: app\ndb:\n  host: localhost\n  port: 5432\ntags: [a, b]\n"
+	files := map[string][]byte{
+		"single.yml":  []byte(content),
+		"general.yml": []byte("imports: []\n" + content),
+	}
+	reader := func(filename string) ([]byte, error) {
+		return files[filename], nil
+	}
+	var ts struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+		Tags []string
+	}
+
+	for _, name := range []string{"single.yml", "general.yml"} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := ProcessFileWithImports(name, &ts, WithReader(reader)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestWithRequireFiles(t *testing.T) {
+	files := map[string][]byte{
+		"configs/app.yml": []byte("imports:\n - {resource: db.yml}\n - {resource: secrets.yml, ignore_errors: true}\n" +
+			" - {resource: prod.yml, when_env: YAML_TEST_REQUIRED_ENV}\nname: app"),
+		"configs/db.yml": []byte("db: {host: localhost}"),
+	}
+	reader := mapReader(files)
+	os.Unsetenv("YAML_TEST_REQUIRED_ENV")
+
+	var ts struct{ Name string }
+	err := ProcessFileWithImports("configs/app.yml", &ts, WithReader(reader), WithRequireFiles("db.yml", "./app.yml"))
+	assert.Nil(t, err)
+
+	err = ProcessFileWithImports("configs/app.yml", &ts, WithReader(reader),
+		WithRequireFiles("db.yml", "secrets.yml"), WithRequireFiles("prod.yml"))
+	assert.True(t, errors.Is(err, RequiredFileErr))
+	assert.EqualError(t, err, "configs/secrets.yml, configs/prod.yml: required config file is not loaded")
+}
+
+func TestRequiredImports(t *testing.T) {
+	files := map[string][]byte{
+		"configs/app.yml": []byte("required_imports:\n - {resource: base.yml, ignore_errors: true}\n" +
+			"imports:\n - {resource: local.yml}\n - {resource: missing.yml}\nname: app"),
+		"configs/base.yml":  []byte("name: base\ndb: {host: localhost, port: 5432}"),
+		"configs/local.yml": []byte("db: {host: db.local}"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("configs/app.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, ImportNotFoundErr))
+	assert.EqualError(t, err, "configs/app.yml -> configs/missing.yml: no such file")
+
+	ts = testStruct{}
+	err = ProcessFileWithImports("configs/app.yml", &ts, WithReader(reader), WithIgnoreAllErrors())
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "db.local", ts.DB.Host, "optional imports override the required ones")
+	assert.Equal(t, 5432, ts.DB.Port)
+
+	delete(files, "configs/base.yml")
+	err = ProcessFileWithImports("configs/app.yml", &ts, WithReader(reader), WithIgnoreAllErrors())
+	assert.True(t, errors.Is(err, ImportNotFoundErr), "required import fails even with ignore_errors")
+	assert.EqualError(t, err, "configs/app.yml -> configs/base.yml: no such file")
+}
+
+func TestCircularImports(t *testing.T) {
+	files := map[string][]byte{
+		"configs/app.yml":     []byte("imports:\n - {resource: base.yml}\nname: app"),
+		"configs/base.yml":    []byte("imports:\n - {resource: shared.yml}\nname: base"),
+		"configs/shared.yml":  []byte("imports:\n - {resource: ./base.yml}\nname: shared"),
+		"configs/chain.yml":   []byte("imports:\n - {resource: a.yml}"),
+		"configs/a.yml":       []byte("imports:\n - {resource: b.yml}"),
+		"configs/b.yml":       []byte("imports:\n - {resource: c.yml}"),
+		"configs/c.yml":       []byte("imports:\n - {resource: a.yml}"),
+		"configs/diamond.yml": []byte("imports:\n - {resource: left.yml}\n - {resource: right.yml}\nname: diamond"),
+		"configs/left.yml":    []byte("imports:\n - {resource: leaf.yml}"),
+		"configs/right.yml":   []byte("imports:\n - {resource: leaf.yml}"),
+		"configs/leaf.yml":    []byte("leaf: true"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		Name string
+		Leaf bool
+	}
+
+	err := ProcessFileWithImports("configs/app.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, CircularImportErr))
+	assert.EqualError(t, err, "configs/base.yml -> configs/shared.yml -> configs/base.yml: circular import")
+
+	err = ProcessFileWithImports("configs/chain.yml", &ts, WithReader(reader))
+	assert.True(t, errors.Is(err, CircularImportErr))
+	assert.EqualError(t, err, "configs/a.yml -> configs/b.yml -> configs/c.yml -> configs/a.yml: circular import")
+
+	err = ProcessFileWithImports("configs/diamond.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "diamond", ts.Name)
+	assert.True(t, ts.Leaf)
+}
+
+func TestImportPriority(t *testing.T) {
+	files := map[string][]byte{
+		"app.yml": []byte("imports:\n" +
+			" - {resource: overrides.yml, priority: 10}\n" +
+			" - {resource: defaults.yml, priority: -1}\n" +
+			" - {resource: local.yml}\n" +
+			" - {resource: env.yml}\n" +
+			"name: app"),
+		"overrides.yml": []byte("db: {host: db.prod}\nlog: warn"),
+		"defaults.yml":  []byte("db: {host: localhost, port: 5432}\nlog: debug\nworkers: 1"),
+		"local.yml":     []byte("log: info\nworkers: 2"),
+		"env.yml":       []byte("workers: 4"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		Name    string
+		Log     string
+		Workers int
+		DB      struct {
+			Host string
+			Port int
+		}
+	}
+
+	err := ProcessFileWithImports("app.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "db.prod", ts.DB.Host, "the highest priority wins")
+	assert.Equal(t, 5432, ts.DB.Port)
+	assert.Equal(t, "warn", ts.Log)
+	assert.Equal(t, 4, ts.Workers, "imports without priority are applied in the order of declaration")
+}
+
+func TestProcessFileWithImportsReader(t *testing.T) {
+	files := map[string][]byte{
+		"embedded/app.yml": []byte("imports:\n - {resource: db.yml}\nname: app"),
+		"embedded/db.yml":  []byte("db: {host: localhost}"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		Name string
+		DB   struct{ Host string }
+	}
+
+	err := ProcessFileWithImportsReader("embedded/app.yml", &ts, reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name)
+	assert.Equal(t, "localhost", ts.DB.Host)
+
+	assert.Equal(t, WrongDstTypeErr, ProcessFileWithImportsReader("embedded/app.yml", ts, reader))
+
+	err = ProcessFileWithImportsReader("embedded/missing.yml", &ts, nil)
+	assert.True(t, os.IsNotExist(err), "ioutil.ReadFile is used without reader")
+}
+
+func TestProcessWithImports(t *testing.T) {
+	files := map[string][]byte{
+		"configs/db.yml":          []byte("imports:\n - {resource: shared/base.yml}\ndb: {host: localhost}"),
+		"configs/shared/base.yml": []byte("db: {host: default, port: 5432}"),
+		"configs/app.yml":         []byte("name: from disk"),
+	}
+	reader := mapReader(files)
+	var ts struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+	}
+	root := []byte("imports:\n - {resource: db.yml}\nname: app")
+
+	result := Result{}
+	err := ProcessWithImports(root, "configs/app.yml", &ts, reader, WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", ts.Name, "the root is not read with the reader")
+	assert.Equal(t, "localhost", ts.DB.Host)
+	assert.Equal(t, 5432, ts.DB.Port)
+	assert.Equal(t, []string{"configs/shared/base.yml", "configs/db.yml", "configs/app.yml"}, result.Loaded)
+
+	err = ProcessWithImports([]byte("imports:\n - {resource: missing.yml}"), "configs/app.yml", &ts, reader)
+	assert.EqualError(t, err, "configs/app.yml -> configs/missing.yml: no such file")
+	assert.Equal(t, WrongDstTypeErr, ProcessWithImports(root, "configs/app.yml", ts, reader))
+
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "db.yml"), []byte("db: {host: disk}"), 0644))
+	err = ProcessWithImports(root, filepath.Join(dir, "app.yml"), &ts, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "disk", ts.DB.Host, "ioutil.ReadFile is used without reader")
+
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "conf.d", "db.yml"), []byte("db: {port: 6432}"), 0644))
+	err = ProcessWithImports([]byte("imports:\n - {resource: conf.d/*.yml}\nname: globbed"), filepath.Join(dir, "app.yml"), &ts, nil)
+	assert.Nil(t, err, "the local files are imported by patterns without reader")
+	assert.Equal(t, "globbed", ts.Name)
+	assert.Equal(t, 6432, ts.DB.Port)
+
+	err = ProcessWithImports([]byte("imports:\n - {resource: missing.yml}"), filepath.Join(dir, "app.yml"), &ts, nil)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestProcessFileWithImportsVerbose(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\n - {resource: config4.yml}\na: config1"),
+		"config2.yml": []byte("imports:\n - {resource: config3.yml}\n - {resource: wrong_file.yaml, ignore_errors: true}\nb: config2"),
+		"config3.yml": []byte("c: config3"),
+		"config4.yml": []byte("imports:\n - {resource: config3.yml}\nd: config4"),
+	}
+	reader := mapReader(files)
+	var ts struct{ A, B, C, D string }
+
+	loaded, err := ProcessFileWithImportsVerbose("config1.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"config3.yml", "config2.yml", "config4.yml", "config1.yml"}, loaded)
+	assert.Equal(t, "config4", ts.D)
+
+	result := Result{}
+	_, err = ProcessFileWithImportsVerbose("config2.yml", &ts, WithReader(reader), WithResult(&result))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"config3.yml", "config2.yml"}, result.Loaded)
+
+	loaded, err = ProcessFileWithImportsVerbose("config1.yml", ts, WithReader(reader))
+	assert.Equal(t, WrongDstTypeErr, err)
+	assert.Nil(t, loaded)
+}
+
+func TestProcessFileWithImportsVerboseAbsolutePaths(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "config1.yml"), []byte("imports:\n - {resource: config2.yml}\na: config1"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "config2.yml"), []byte("b: config2"), 0644))
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	relDir, err := filepath.Rel(wd, dir)
+	assert.Nil(t, err)
+	var ts struct{ A, B string }
+
+	loaded, err := ProcessFileWithImportsVerbose(filepath.Join(relDir, "config1.yml"), &ts)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "config2.yml"), filepath.Join(dir, "config1.yml")}, loaded,
+		"the files of the default reader are listed with absolute paths")
+}
+
+func TestProcessFileWithImportsStrict(t *testing.T) {
+	files := map[string][]byte{
+		"config1.yml": []byte("imports:\n - {resource: config2.yml}\na: config1"),
+		"config2.yml": []byte("imports:\n - {resource: config3.yml}\nb: config2"),
+		"config3.yml": []byte("nested:\n  valeu: config3"),
+	}
+	reader := mapReader(files)
+	type testStruct struct {
+		A, B   string
+		Nested struct{ Value string }
+	}
+
+	var ts testStruct
+	err := ProcessFileWithImports("config1.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "config2", ts.B)
+
+	var strict testStruct
+	err = ProcessFileWithImportsStrict("config1.yml", &strict, WithReader(reader))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "config3.yml: yaml: unmarshal errors:\n  line 2: field valeu not found")
+	assert.True(t, errors.Is(err, ImportParseErr))
+	var typeErr *yaml.TypeError
+	assert.True(t, errors.As(err, &typeErr))
+
+	files["config3.yml"] = []byte("nested:\n  value: config3")
+	err = ProcessFileWithImportsStrict("config1.yml", &strict, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{A: "config1", B: "config2", Nested: struct{ Value string }{"config3"}}, strict)
+
+	files["config1.yml"] = []byte("imports:\n - {resource: config2.yml}\na: config1\nc: unknown")
+	err = ProcessFileWithImportsStrict("config1.yml", &strict, WithReader(reader))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "config1.yml: yaml: unmarshal errors:\n  line 4: field c not found")
+
+	generic := map[string]interface{}{}
+	err = ProcessFileWithImportsStrict("config1.yml", &generic, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "unknown", generic["c"])
+}
+
+func TestDiamondImports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config1.yml": "imports:\n - {resource: config2.yml}\n - {resource: sub/config3.yml}\nname: config1",
+		"config2.yml": "imports:\n - {resource: ./config4.yml}\nlevel: config2",
+		"config4.yml": "level: config4\nshared: config4",
+	}
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	files["sub/config3.yml"] = "imports:\n - {resource: sub/../config4.yml}\nlevel: config3"
+	for name, content := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	var reads []string
+	reader := func(filename string) ([]byte, error) {
+		reads = append(reads, filename)
+		return ioutil.ReadFile(filename)
+	}
+	var ts struct{ Name, Level, Shared string }
+	err := ProcessFileWithImports(filepath.Join(dir, "config1.yml"), &ts, WithReader(reader), WithMaxCacheBytes(1))
+	assert.Nil(t, err)
+	assert.Equal(t, "config3", ts.Level)
+	assert.Equal(t, "config4", ts.Shared)
+	shared := 0
+	for _, file := range reads {
+		if filepath.Base(file) == "config4.yml" {
+			shared++
+		}
+	}
+	assert.Equal(t, 2, shared, "the shared file is read once to discover and once to load")
+}
+
+func TestMaxImportDepth(t *testing.T) {
+	// chain builds the files of the chain of n imports: config0.yml imports config1.yml and so on
+	chain := func(n int) ReadFileFunc {
+		return func(filename string) ([]byte, error) {
+			var i int
+			if _, err := fmt.Sscanf(filename, "config%d.yml", &i); err != nil || i > n {
+				return nil, errors.New("no such file")
+			}
+			if i == n {
+				return []byte(fmt.Sprintf("depth: %d", i)), nil
+			}
+			return []byte(fmt.Sprintf("imports:\n - {resource: config%d.yml}\ndepth: %d", i+1, i)), nil
+		}
+	}
+	var ts struct{ Depth int }
+
+	err := ProcessFileWithImports("config0.yml", &ts, WithReader(chain(50)))
+	assert.Nil(t, err)
+	err = ProcessFileWithImports("config0.yml", &ts, WithReader(chain(51)))
+	assert.True(t, errors.Is(err, MaxImportDepthExceededErr))
+	assert.EqualError(t, err, "config50.yml imports config51.yml at depth 51, limit is 50: import chain is too deep")
+
+	err = ProcessFileWithImports("config0.yml", &ts, WithReader(chain(3)), WithMaxImportDepth(3))
+	assert.Nil(t, err)
+	err = ProcessFileWithImports("config0.yml", &ts, WithReader(chain(4)), WithMaxImportDepth(3))
+	assert.True(t, errors.Is(err, MaxImportDepthExceededErr))
+	err = ProcessFileWithImports("config0.yml", &ts, WithReader(chain(60)), WithMaxImportDepth(0))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, ts.Depth)
+}
+
+func TestWithMaxImports(t *testing.T) {
+	// fanOut builds the files of config.yml importing n generated files
+	fanOut := func(n int) ReadFileFunc {
+		return func(filename string) ([]byte, error) {
+			if filename != "config.yml" {
+				return []byte("name: " + filename), nil
+			}
+			imports := make([]string, 0, n)
+			for i := 0; i < n; i++ {
+				imports = append(imports, fmt.Sprintf(" - generated%d.yml", i))
+			}
+			return []byte("imports:\n" + strings.Join(imports, "\n")), nil
+		}
+	}
+	var ts struct{ Name string }
+
+	err := ProcessFileWithImports("config.yml", &ts, WithReader(fanOut(999)))
+	assert.Nil(t, err)
+	assert.Equal(t, "generated998.yml", ts.Name)
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(fanOut(1000)))
+	assert.True(t, errors.Is(err, TooManyImportsErr))
+	assert.EqualError(t, err, "config.yml imports generated0.yml, file 1001 of the tree, limit is 1000: imports tree has too many files")
+
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(fanOut(9)), WithMaxImports(10))
+	assert.Nil(t, err)
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(fanOut(10)), WithMaxImports(10))
+	assert.True(t, errors.Is(err, TooManyImportsErr))
+	err = ProcessFileWithImports("config.yml", &ts, WithReader(fanOut(1200)), WithMaxImports(0))
+	assert.Nil(t, err)
+}
+
+func TestResolvePath(t *testing.T) {
+	testCases := []struct {
+		configPath, resource, expected string
+	}{
+		{"configs/app.yml", "base.yml", "configs/base.yml"},
+		{"configs/app.yml", "../shared/base.yml", "shared/base.yml"},
+		{"configs/app.yml", "../../shared/base.yml", "../shared/base.yml"},
+		{"configs/app.yml", "./base.yml", "configs/base.yml"},
+		{"configs/app.yml", "sub//./base.yml", "configs/sub/base.yml"},
+		{"app.yml", "./base.yml", "base.yml"},
+		{"configs/app.yml", "/etc/app/base.yml", "/etc/app/base.yml"},
+		{"configs/app.yml", "/etc//app/../app/./base.yml", "/etc/app/base.yml"},
+		{"configs/app.yml", "https://config.internal/base.yml", "https://config.internal/base.yml"},
+		{"configs/app.yml", "http://config.internal/base.yml?env=prod", "http://config.internal/base.yml?env=prod"},
+		{"configs/app.yml", "sftp://host/etc/base.yml", "sftp://host/etc/base.yml"},
+		{"https://config.internal/app/config.yml", "base.yml", "https://config.internal/app/base.yml"},
+		{"https://config.internal/app/config.yml", "../shared/./base.yml", "https://config.internal/shared/base.yml"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, newOptions(nil).resolvePath(tc.configPath, tc.resource), tc.resource)
+	}
+	slashPaths := &options{slashPaths: true}
+	assert.Equal(t, "shared/base.yml", slashPaths.resolvePath("configs/app.yml", "../shared//./base.yml"))
+	assert.Equal(t, "/shared/base.yml", slashPaths.resolvePath("configs/app.yml", "/shared/../shared/base.yml"))
+
+	files := map[string][]byte{
+		"configs/app.yml": []byte("imports:\n - {resource: 'https://config.internal/base.yml?env=prod'}\n - {resource: local.yml}\nname: app"),
+		"https://config.internal/base.yml?env=prod": []byte("region: eu"),
+		"configs/local.yml":                         []byte("port: 8080"),
+	}
+	var requested []string
+	reader := func(filename string) ([]byte, error) {
+		requested = append(requested, filename)
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+	var ts struct {
+		Name, Region string
+		Port         int
+	}
+	err := ProcessFileWithImports("configs/app.yml", &ts, WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, "eu", ts.Region)
+	assert.Equal(t, []string{"configs/app.yml", "configs/local.yml", "https://config.internal/base.yml?env=prod"}, requested)
 }
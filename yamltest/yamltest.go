@@ -0,0 +1,43 @@
+// Package yamltest provides helpers for testing the configs processed with github.com/lispad/yaml.
+//
+// RecordingReader wraps the reader of the configs and records the file names requested by the loader,
+// so the tests can check the order of the imports and the resolution of their paths directly.
+// MapReader serves the configs of the test from memory.
+package yamltest
+
+import (
+	"os"
+	"sync"
+
+	"github.com/lispad/yaml"
+)
+
+// RecordingReader returns the reader which reads the files with base and appends the requested file names
+// to the returned list in the order of the requests, failed ones included
+// The files can be read again by the loader, so the list may contain duplicates
+func RecordingReader(base yaml.ReadFileFunc) (yaml.ReadFileFunc, *[]string) {
+	var (
+		mu        sync.Mutex
+		requested = &[]string{}
+	)
+	reader := func(filename string) ([]byte, error) {
+		mu.Lock()
+		*requested = append(*requested, filename)
+		mu.Unlock()
+
+		return base(filename)
+	}
+
+	return reader, requested
+}
+
+// MapReader returns the reader of the files of the map, the files changed in the map are read with the new content
+// The files which are not in the map fail to read with *os.PathError matching os.ErrNotExist, like ioutil.ReadFile
+func MapReader(files map[string][]byte) yaml.ReadFileFunc {
+	return func(filename string) ([]byte, error) {
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, &os.PathError{Op: "open", Path: filename, Err: os.ErrNotExist}
+	}
+}
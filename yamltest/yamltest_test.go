@@ -0,0 +1,46 @@
+package yamltest
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/lispad/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingReader(t *testing.T) {
+	files := map[string][]byte{
+		"configs/app.yml":          []byte("imports:\n - {resource: base/db.yml}\n - {resource: local.yml, ignore_errors: true}\nname: app"),
+		"configs/base/db.yml":      []byte("imports:\n - {resource: base/network.yml}\ndb: {host: localhost}"),
+		"configs/base/network.yml": []byte("port: 8080"),
+	}
+	reader, requested := RecordingReader(MapReader(files))
+	var ts struct {
+		Name string
+		Port int
+		DB   struct{ Host string }
+	}
+
+	err := yaml.ProcessFileWithImports("configs/app.yml", &ts, yaml.WithReader(reader))
+	assert.Nil(t, err)
+	assert.Equal(t, 8080, ts.Port)
+	assert.Equal(t, []string{"configs/app.yml", "configs/local.yml", "configs/base/db.yml", "configs/base/network.yml"}, *requested)
+}
+
+func TestMapReader(t *testing.T) {
+	files := map[string][]byte{"app.yml": []byte("name: app")}
+	reader := MapReader(files)
+
+	data, err := reader("app.yml")
+	assert.Nil(t, err)
+	assert.Equal(t, "name: app", string(data))
+
+	files["app.yml"] = []byte("name: changed")
+	data, _ = reader("app.yml")
+	assert.Equal(t, "name: changed", string(data), "the map is read on every call")
+
+	_, err = reader("missing.yml")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.EqualError(t, err, "open missing.yml: file does not exist")
+}